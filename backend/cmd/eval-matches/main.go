@@ -0,0 +1,133 @@
+// Command eval-matches measures match ranking quality against real exchanges and compares
+// two scoring configurations.
+//
+//	go run ./cmd/eval-matches -a default -b ./weights.json -days 90 -k 5,10,20
+//
+// A configuration is "default", "heuristic" (default weights without the trained model) or a
+// JSON file of MatchScoringConfig fields; omitted fields keep their default values.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"skillswap-backend/config"
+	"skillswap-backend/services"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	configA := flag.String("a", "default", "baseline scoring configuration")
+	configB := flag.String("b", "heuristic", "candidate scoring configuration")
+	days := flag.Int("days", 90, "evaluate against exchanges created in the last N days")
+	cutoffs := flag.String("k", "5,10,20", "comma-separated rank cutoffs")
+	maxUsers := flag.Int("max-users", 0, "evaluate at most this many users (0 = all)")
+	jsonOut := flag.String("json", "", "also write both results as JSON to this file")
+	flag.Parse()
+
+	ks, err := parseCutoffs(*cutoffs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config.LoadConfig()
+	config.ConnectDatabase()
+	config.DB.Logger = config.DB.Logger.LogMode(logger.Warn)
+
+	if err := services.InitRankingModel(); err != nil {
+		log.Printf("Ranking model not loaded, configurations will use heuristic scoring: %v", err)
+	}
+
+	scoringA, err := loadScoring(*configA)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scoringB, err := loadScoring(*configB)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	judgments, err := services.LoadRelevanceJudgments(time.Now().AddDate(0, 0, -*days), *maxUsers)
+	if err != nil {
+		log.Fatal("Failed to load exchanges:", err)
+	}
+	if len(judgments) == 0 {
+		log.Fatalf("No accepted or completed exchanges in the last %d days", *days)
+	}
+	log.Printf("Evaluating %d users", len(judgments))
+
+	resultA, err := services.EvaluateMatchRanking(scoringA, judgments, ks)
+	if err != nil {
+		log.Fatal("Failed to evaluate configuration A:", err)
+	}
+	resultB, err := services.EvaluateMatchRanking(scoringB, judgments, ks)
+	if err != nil {
+		log.Fatal("Failed to evaluate configuration B:", err)
+	}
+
+	if resultA.SkippedUsers > 0 {
+		log.Printf("Skipped %d users whose accounts were deleted", resultA.SkippedUsers)
+	}
+	printReport(resultA, resultB, ks)
+
+	if *jsonOut != "" {
+		data, err := json.MarshalIndent(map[string]*services.MatchEvaluationResult{"a": resultA, "b": resultB}, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*jsonOut, data, 0o644); err != nil {
+			log.Fatal("Failed to write JSON report:", err)
+		}
+	}
+}
+
+func loadScoring(name string) (services.MatchScoringConfig, error) {
+	switch name {
+	case "default":
+		return services.DefaultMatchScoring, nil
+	case "heuristic":
+		scoring := services.DefaultMatchScoring
+		scoring.Name = "heuristic"
+		scoring.UseRankingModel = false
+		return scoring, nil
+	default:
+		return services.LoadMatchScoringConfig(name)
+	}
+}
+
+func parseCutoffs(value string) ([]int, error) {
+	var ks []int
+	for _, part := range strings.Split(value, ",") {
+		k, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || k < 1 {
+			return nil, fmt.Errorf("invalid cutoff %q", part)
+		}
+		ks = append(ks, k)
+	}
+	return ks, nil
+}
+
+func printReport(a, b *services.MatchEvaluationResult, ks []int) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "metric\tA: %s\tB: %s\tdelta\t\n", a.Config, b.Config)
+
+	row := func(name string, va, vb float64) {
+		fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%+.4f\t\n", name, va, vb, vb-va)
+	}
+	row("user_coverage", a.UserCoverage, b.UserCoverage)
+	row("average_matches", a.AverageMatches, b.AverageMatches)
+	for _, k := range ks {
+		row(fmt.Sprintf("precision@%d", k), a.PrecisionAtK[k], b.PrecisionAtK[k])
+		row(fmt.Sprintf("recall@%d", k), a.RecallAtK[k], b.RecallAtK[k])
+		row(fmt.Sprintf("ndcg@%d", k), a.NDCGAtK[k], b.NDCGAtK[k])
+		row(fmt.Sprintf("catalog_coverage@%d", k), a.CatalogCoverage[k], b.CatalogCoverage[k])
+	}
+	w.Flush()
+}
@@ -6,6 +6,8 @@ import (
 	"skillswap-backend/middleware"
 	"skillswap-backend/models"
 	"skillswap-backend/routes"
+	"skillswap-backend/services"
+	_ "time/tzdata" // Users' notification timezones work even without system zoneinfo
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,17 +23,62 @@ func main() {
 	config.ConnectDatabase()
 
 	// Auto migrate database tables
-	err := config.DB.AutoMigrate(&models.User{}, &models.Skill{}, &models.Exchange{}, &models.ChatRoom{}, &models.Message{})
+	err := config.DB.AutoMigrate(&models.User{}, &models.Skill{}, &models.Exchange{}, &models.ChatRoom{}, &models.Message{},
+		&models.ChatRoomMember{}, &models.MessageEdit{}, &models.MessageReaction{},
+		&models.Review{}, &models.UserRating{}, &models.LoginAttempt{}, &models.LoginThrottle{},
+		&models.Attachment{}, &models.PrivacySettings{},
+		&models.Endorsement{}, &models.PortfolioItem{},
+		&models.SavedSearch{}, &models.Notification{},
+		&models.SkillBookmark{}, &models.UserFollow{},
+		&models.MatchFeedback{},
+		&models.Experiment{}, &models.ExperimentVariant{}, &models.ExperimentExposure{},
+		&models.ScheduledJobState{}, &models.SentNotification{},
+		&models.NotificationPreference{}, &models.NotificationSettings{}, &models.QueuedNotification{},
+		&models.OutboxMessage{}, &models.WebhookSubscription{}, &models.WebhookDelivery{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Move chat rooms created before memberships existed
+	if err := services.MigrateChatRooms(); err != nil {
+		log.Fatal("Failed to migrate chat rooms:", err)
+	}
+
+	// Initialize file storage
+	if err := services.InitStorage(); err != nil {
+		log.Fatal("Failed to initialize file storage:", err)
+	}
+
+	// Load the trained ranking model, if any
+	if err := services.InitRankingModel(); err != nil {
+		log.Printf("Ranking model not loaded, using heuristic match scoring: %v", err)
+	}
+
+	// Parse the email templates now rather than on the first email
+	if err := services.LoadEmailTemplates(); err != nil {
+		log.Fatal("Failed to load email templates:", err)
+	}
+
+	// Deliver notifications over email, in-app and webhook channels
+	services.InitNotifications()
+
+	// Relay live chat and notification events between instances
+	go services.Push().Listen()
+
+	// Background jobs; with several instances only the advisory lock holder runs them
+	if config.AppConfig.SchedulerEnabled {
+		startScheduler()
+	}
+
 	// Initialize Gin router
 	router := gin.Default()
 
 	// Configure router to handle trailing slashes
 	router.RedirectTrailingSlash = false
 
+	// Cap multipart memory; larger uploads spill to temp files
+	router.MaxMultipartMemory = 8 << 20
+
 	// Add CORS middleware
 	router.Use(middleware.CORSMiddleware())
 
@@ -51,3 +98,42 @@ func main() {
 	log.Printf("Starting server on port %s", config.AppConfig.Port)
 	log.Fatal(router.Run(port))
 }
+
+func startScheduler() {
+	scheduler := &services.Scheduler{}
+	accountService := &services.AccountService{}
+	savedSearchService := &services.SavedSearchService{}
+	notificationService := &services.NotificationService{}
+	notificationPreferenceService := &services.NotificationPreferenceService{}
+	outboxService := &services.OutboxService{}
+	webhookService := &services.WebhookService{}
+
+	jobs := []struct {
+		name string
+		spec string
+		run  func() error
+	}{
+		// Purge deleted accounts once their retention period is over
+		{"purge_deleted_accounts", "0 3 * * *", accountService.PurgeExpiredAccounts},
+		// Alert users about new skills matching their saved searches
+		{"saved_search_alerts", "*/15 * * * *", savedSearchService.RunDueSearches},
+		{"weekly_digest", config.AppConfig.WeeklyDigestCron, notificationService.SendWeeklyDigests},
+		// Send email held back by quiet hours, and daily digests
+		{"notification_queue", "*/15 * * * *", notificationPreferenceService.FlushQueued},
+		{"new_match_notifications", config.AppConfig.MatchNotifyCron, func() error {
+			return notificationService.NotifyNewMatches(config.AppConfig.MatchNotifyMinScore)
+		}},
+		// Delete delivered outbox messages once they're a week old
+		{"outbox_cleanup", "30 3 * * *", outboxService.PurgeDelivered},
+		// Delete webhook delivery logs after 30 days
+		{"webhook_delivery_cleanup", "45 3 * * *", webhookService.PurgeDeliveries},
+	}
+
+	for _, job := range jobs {
+		if err := scheduler.Register(job.name, job.spec, job.run); err != nil {
+			log.Fatal("Failed to schedule job:", err)
+		}
+	}
+
+	go scheduler.Start()
+}
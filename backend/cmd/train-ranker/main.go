@@ -0,0 +1,90 @@
+// Command train-ranker trains the match ranking model from historical exchanges.
+//
+//	go run ./cmd/train-ranker -negatives 3 -holdout 0.2 -promote
+//
+// Each run writes a versioned artifact to RANKING_MODEL_DIR; with -promote it also
+// becomes ranking_model.json, which the API loads at startup.
+package main
+
+import (
+	"flag"
+	"log"
+	"skillswap-backend/config"
+	"skillswap-backend/services"
+	"sort"
+
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	negatives := flag.Int("negatives", 3, "implicit negatives sampled per positive exchange")
+	holdout := flag.Float64("holdout", 0.2, "fraction of the most recent examples held out for evaluation")
+	epochs := flag.Int("epochs", 500, "gradient descent epochs")
+	learningRate := flag.Float64("lr", 0.1, "learning rate")
+	l2 := flag.Float64("l2", 0.01, "L2 regularization strength")
+	seed := flag.Int64("seed", 1, "random seed for negative sampling")
+	minExamples := flag.Int("min-examples", 50, "refuse to train on fewer examples")
+	promote := flag.Bool("promote", false, "make the trained model the one served by the API")
+	flag.Parse()
+
+	config.LoadConfig()
+	config.ConnectDatabase()
+	config.DB.Logger = config.DB.Logger.LogMode(logger.Warn)
+
+	rankingService := &services.RankingService{}
+	examples, err := rankingService.BuildTrainingSet(*negatives, *seed)
+	if err != nil {
+		log.Fatal("Failed to build training set:", err)
+	}
+	if len(examples) < *minExamples {
+		log.Fatalf("Only %d training examples (need %d); keep using heuristic scoring", len(examples), *minExamples)
+	}
+
+	train, test, err := services.SplitByTime(examples, *holdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Training on %d examples, evaluating on %d", len(train), len(test))
+
+	model, err := services.TrainLogisticRegression(train, services.RankingFeatureNames, services.TrainingOptions{
+		Epochs:       *epochs,
+		LearningRate: *learningRate,
+		L2:           *l2,
+	})
+	if err != nil {
+		log.Fatal("Failed to train model:", err)
+	}
+
+	model.Metrics = map[string]float64{}
+	for name, value := range model.Evaluate(train) {
+		model.Metrics["train_"+name] = value
+	}
+	for name, value := range model.Evaluate(test) {
+		model.Metrics["test_"+name] = value
+	}
+
+	names := make([]string, 0, len(model.Metrics))
+	for name := range model.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Printf("  %-20s %.4f", name, model.Metrics[name])
+	}
+	for i, name := range model.FeatureNames {
+		log.Printf("  weight %-24s %+.4f", name, model.Weights[i])
+	}
+
+	path, err := model.Save(config.AppConfig.RankingModelDir)
+	if err != nil {
+		log.Fatal("Failed to save model:", err)
+	}
+	log.Printf("Saved ranking model %s to %s", model.Version, path)
+
+	if *promote {
+		if err := model.Promote(config.AppConfig.RankingModelDir); err != nil {
+			log.Fatal("Failed to promote model:", err)
+		}
+		log.Printf("Promoted ranking model %s; restart the API to serve it", model.Version)
+	}
+}
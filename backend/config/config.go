@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
@@ -22,6 +23,54 @@ type Config struct {
 	Port        string
 	GinMode     string
 	FrontendURL string
+
+	// Login throttling
+	LoginMaxAccountFailures int
+	LoginMaxIPFailures      int
+	LoginLockoutMinutes     int
+	LoginBackoffBaseSeconds int
+
+	// Days a deleted account is kept (anonymized) before it is purged
+	AccountRetentionDays int
+
+	// File storage
+	StorageDriver          string // local or s3
+	StorageLocalPath       string
+	S3Endpoint             string
+	S3Region               string
+	S3Bucket               string
+	S3AccessKey            string
+	S3SecretKey            string
+	S3UsePathStyle         bool
+	AvatarMaxBytes         int
+	ChatAttachmentMaxBytes int
+	PortfolioFileMaxBytes  int
+	FileURLTTLMinutes      int
+
+	// Directory holding trained ranking model artifacts
+	RankingModelDir string
+
+	// Minutes after sending during which a message can be deleted for everyone
+	ChatDeleteWindowMinutes int
+
+	// Notification channels
+	SMTPHost          string
+	SMTPPort          string
+	SMTPUser          string
+	SMTPPass          string
+	SMTPFrom          string // Defaults to SMTPUser
+	NotifyWorkers     int    // Outbox delivery workers per instance
+	NotifyMaxAttempts int    // Deliveries tried before a message is dead-lettered
+	NotifyDigestHour  int    // Local hour at which daily digests go out
+	PublicAPIURL      string // Base URL of this API as users reach it, for links in emails
+
+	WebhookAllowPrivateNetworks bool // Let webhooks reach private and loopback addresses, for local development
+
+	// Background jobs (cron expressions are evaluated in the server's local time)
+	SchedulerEnabled    bool
+	WeeklyDigestCron    string
+	MatchNotifyCron     string
+	MatchNotifyMinScore int
 }
 
 var AppConfig *Config
@@ -44,6 +93,47 @@ func LoadConfig() {
 		Port:        getEnv("PORT", "8080"),
 		GinMode:     getEnv("GIN_MODE", "debug"),
 		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+
+		LoginMaxAccountFailures: getEnvInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+		LoginMaxIPFailures:      getEnvInt("LOGIN_MAX_IP_FAILURES", 20),
+		LoginLockoutMinutes:     getEnvInt("LOGIN_LOCKOUT_MINUTES", 15),
+		LoginBackoffBaseSeconds: getEnvInt("LOGIN_BACKOFF_BASE_SECONDS", 1),
+
+		AccountRetentionDays: getEnvInt("ACCOUNT_RETENTION_DAYS", 30),
+
+		StorageDriver:          getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalPath:       getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+		S3Endpoint:             getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+		S3Region:               getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:               getEnv("S3_BUCKET", ""),
+		S3AccessKey:            getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:            getEnv("S3_SECRET_KEY", ""),
+		S3UsePathStyle:         getEnv("S3_USE_PATH_STYLE", "true") == "true",
+		AvatarMaxBytes:         getEnvInt("AVATAR_MAX_BYTES", 5<<20),
+		ChatAttachmentMaxBytes: getEnvInt("CHAT_ATTACHMENT_MAX_BYTES", 10<<20),
+		PortfolioFileMaxBytes:  getEnvInt("PORTFOLIO_FILE_MAX_BYTES", 10<<20),
+		FileURLTTLMinutes:      getEnvInt("FILE_URL_TTL_MINUTES", 15),
+
+		RankingModelDir: getEnv("RANKING_MODEL_DIR", "./ml"),
+
+		ChatDeleteWindowMinutes: getEnvInt("CHAT_DELETE_WINDOW_MINUTES", 60),
+
+		SMTPHost:          getEnv("SMTP_HOST", "smtp.gmail.com"),
+		SMTPPort:          getEnv("SMTP_PORT", "587"),
+		SMTPUser:          getEnv("SMTP_USER", ""),
+		SMTPPass:          getEnv("SMTP_PASS", ""),
+		SMTPFrom:          getEnv("SMTP_FROM", os.Getenv("SMTP_USER")),
+		NotifyWorkers:     getEnvInt("NOTIFY_WORKERS", 4),
+		NotifyMaxAttempts: getEnvInt("NOTIFY_MAX_ATTEMPTS", 8),
+		NotifyDigestHour:  getEnvInt("NOTIFY_DIGEST_HOUR", 8),
+		PublicAPIURL:      getEnv("PUBLIC_API_URL", "http://localhost:8080"),
+
+		WebhookAllowPrivateNetworks: getEnv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+
+		SchedulerEnabled:    getEnv("SCHEDULER_ENABLED", "true") == "true",
+		WeeklyDigestCron:    getEnv("WEEKLY_DIGEST_CRON", "0 9 * * 1"),
+		MatchNotifyCron:     getEnv("MATCH_NOTIFY_CRON", "0 */6 * * *"),
+		MatchNotifyMinScore: getEnvInt("MATCH_NOTIFY_MIN_SCORE", 120),
 	}
 }
 
@@ -75,3 +165,13 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Warning: invalid integer for %s: %q, using default %d", key, value, defaultValue)
+	}
+	return defaultValue
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminController struct{}
+
+// GetLoginAttempts lists login audit records, failed attempts by default
+func (ac *AdminController) GetLoginAttempts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	email := c.Query("email")
+	ipAddress := c.Query("ip")
+	userID := c.Query("user_id")
+	success := c.DefaultQuery("success", "false")
+	since := c.Query("since")
+
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.LoginAttempt{})
+
+	if email != "" {
+		query = query.Where("email = ?", strings.ToLower(strings.TrimSpace(email)))
+	}
+
+	if ipAddress != "" {
+		query = query.Where("ip_address = ?", ipAddress)
+	}
+
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if success != "all" {
+		query = query.Where("success = ?", success == "true")
+	}
+
+	if since != "" {
+		sinceTime, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since timestamp, expected RFC3339"})
+			return
+		}
+		query = query.Where("created_at >= ?", sinceTime)
+	}
+
+	var attempts []models.LoginAttempt
+	var total int64
+
+	// Get total count
+	query.Count(&total)
+
+	// Get paginated results
+	if err := query.Limit(limit).Offset(offset).Order("created_at DESC").Find(&attempts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch login attempts"})
+		return
+	}
+
+	response := gin.H{
+		"login_attempts": attempts,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOutbox lists outbox messages, newest first, with the number of messages in each status
+func (ac *AdminController) GetOutbox(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+	status := c.Query("status")
+	event := c.Query("event")
+	channel := c.Query("channel")
+	userID := c.Query("user_id")
+
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.OutboxMessage{})
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var messages []models.OutboxMessage
+	var total int64
+
+	// Get total count
+	query.Count(&total)
+
+	// Get paginated results
+	if err := query.Limit(limit).Offset(offset).Order("created_at DESC").Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch outbox messages"})
+		return
+	}
+
+	outboxService := &services.OutboxService{}
+	counts, err := outboxService.StatusCounts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count outbox messages"})
+		return
+	}
+
+	response := gin.H{
+		"messages": messages,
+		"counts":   counts,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ReplayOutboxMessage queues a dead-lettered outbox message for delivery again
+func (ac *AdminController) ReplayOutboxMessage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid outbox message ID"})
+		return
+	}
+
+	outboxService := &services.OutboxService{}
+	if err := outboxService.Replay(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Dead outbox message not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay outbox message"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Outbox message queued for delivery"})
+}
+
+// ReplayOutbox queues every dead-lettered outbox message, optionally only for one event and channel
+func (ac *AdminController) ReplayOutbox(c *gin.Context) {
+	outboxService := &services.OutboxService{}
+	replayed, err := outboxService.ReplayDead(c.Query("event"), c.Query("channel"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay outbox messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
+
+// GetEmailTemplates lists the email templates and the locales they can be rendered in
+func (ac *AdminController) GetEmailTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"templates": services.EmailTemplateNames(),
+		"locales":   services.EmailLocales(),
+	})
+}
+
+// PreviewEmailTemplate renders an email template with sample data. format=html or text returns that
+// body as-is, for viewing in a browser; otherwise the subject and both bodies are returned as JSON.
+func (ac *AdminController) PreviewEmailTemplate(c *gin.Context) {
+	locale := c.DefaultQuery("locale", services.DefaultLocale)
+	if _, ok := services.MatchLocale(locale); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale"})
+		return
+	}
+
+	email, err := services.PreviewEmail(c.Param("name"), locale)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownEmailTemplate) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render email template: " + err.Error()})
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(email.HTMLBody))
+	case "text":
+		c.String(http.StatusOK, email.Body)
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"template": c.Param("name"),
+			"locale":   services.ResolveLocale(locale),
+			"subject":  email.Subject,
+			"text":     email.Body,
+			"html":     email.HTMLBody,
+		})
+	}
+}
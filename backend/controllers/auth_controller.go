@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
 	"skillswap-backend/config"
 	"skillswap-backend/models"
+	"skillswap-backend/services"
 	"skillswap-backend/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -26,8 +30,8 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  models.User `json:"user"`
+	Token string         `json:"token"`
+	User  models.Account `json:"user"`
 }
 
 func (ac *AuthController) Register(c *gin.Context) {
@@ -57,6 +61,7 @@ func (ac *AuthController) Register(c *gin.Context) {
 		Username: req.Username,
 		Password: hashedPassword,
 		FullName: req.FullName,
+		Locale:   services.LocaleFromAcceptLanguage(c.GetHeader("Accept-Language")),
 	}
 
 	if err := config.DB.Create(&user).Error; err != nil {
@@ -73,7 +78,7 @@ func (ac *AuthController) Register(c *gin.Context) {
 
 	response := AuthResponse{
 		Token: token,
-		User:  user,
+		User:  models.NewAccount(user),
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -86,10 +91,36 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	throttleService := &services.LoginThrottleService{}
+	clientIP := c.ClientIP()
+	userAgent := c.Request.UserAgent()
+
+	// Reject early if the account or IP is locked out or backing off
+	if status := throttleService.Check(req.Email, clientIP); !status.Allowed {
+		reason := "throttled"
+		message := "Too many failed login attempts, please try again later"
+		if status.Locked {
+			reason = "locked"
+			message = "Account temporarily locked due to too many failed login attempts"
+			if status.Scope == services.ThrottleScopeIP {
+				message = "Too many failed login attempts from this network, please try again later"
+			}
+		}
+		throttleService.RecordAttempt(req.Email, clientIP, userAgent, nil, false, reason)
+
+		retryAfter := int(status.RetryAfter.Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": message, "retry_after": retryAfter})
+		return
+	}
+
 	// Find user by email
 	var user models.User
 	if err := config.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			// Count failures for unknown emails too so lockouts don't reveal which accounts exist
+			throttleService.RecordFailure(req.Email, clientIP)
+			throttleService.RecordAttempt(req.Email, clientIP, userAgent, nil, false, "invalid_credentials")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
@@ -99,10 +130,24 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 	// Check password
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		locked, lockedUntil := throttleService.RecordFailure(req.Email, clientIP)
+		throttleService.RecordAttempt(req.Email, clientIP, userAgent, &user.ID, false, "invalid_credentials")
+
+		// Let the account owner know their account was locked
+		if locked {
+			notificationService := &services.NotificationService{}
+			if err := notificationService.NotifyAccountLocked(config.DB, user, clientIP, lockedUntil); err != nil {
+				log.Printf("Failed to notify user %d about account lockout: %v", user.ID, err)
+			}
+		}
+
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
+	throttleService.RecordSuccess(req.Email)
+	throttleService.RecordAttempt(req.Email, clientIP, userAgent, &user.ID, true, "")
+
 	// Generate JWT token
 	token, err := utils.GenerateJWT(user.ID, user.Email, config.AppConfig.JWTSecret)
 	if err != nil {
@@ -112,7 +157,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 	response := AuthResponse{
 		Token: token,
-		User:  user,
+		User:  models.NewAccount(user),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -131,7 +176,7 @@ func (ac *AuthController) GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, models.NewAccount(user))
 }
 
 func (ac *AuthController) UpdateProfile(c *gin.Context) {
@@ -152,6 +197,7 @@ func (ac *AuthController) UpdateProfile(c *gin.Context) {
 		Bio      string `json:"bio"`
 		Avatar   string `json:"avatar"`
 		Location string `json:"location"`
+		Locale   string `json:"locale"` // Email language; unchanged when empty
 	}
 
 	var req UpdateRequest
@@ -166,15 +212,25 @@ func (ac *AuthController) UpdateProfile(c *gin.Context) {
 	user.Avatar = req.Avatar
 	user.Location = req.Location
 
+	if req.Locale != "" {
+		locale, ok := services.MatchLocale(req.Locale)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale"})
+			return
+		}
+		user.Locale = locale
+	}
+
 	if err := config.DB.Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, models.NewAccount(user))
 }
 
 func (ac *AuthController) GetUserByID(c *gin.Context) {
+	viewerID := utils.GetUserIDFromContext(c)
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseUint(userIDStr, 10, 32)
 	if err != nil {
@@ -183,10 +239,170 @@ func (ac *AuthController) GetUserByID(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := config.DB.Preload("OfferedSkills").First(&user, uint(userID)).Error; err != nil {
+	if err := config.DB.First(&user, uint(userID)).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	profileService := &services.ProfileService{}
+	settings := profileService.GetPrivacySettings(user.ID)
+
+	// Hidden profiles look the same as missing ones
+	if !profileService.CanView(settings, &viewerID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profileService.BuildPublicProfile(user, settings))
+}
+
+// GetPublicProfile returns a shareable profile for users who opted in; no login required
+func (ac *AuthController) GetPublicProfile(c *gin.Context) {
+	var user models.User
+	if err := config.DB.Where("username = ?", c.Param("username")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+
+	profileService := &services.ProfileService{}
+	settings := profileService.GetPrivacySettings(user.ID)
+
+	if !profileService.CanView(settings, nil) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profileService.BuildPublicProfile(user, settings))
+}
+
+// GetPrivacySettings returns the current user's privacy settings
+func (ac *AuthController) GetPrivacySettings(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	profileService := &services.ProfileService{}
+	c.JSON(http.StatusOK, profileService.GetPrivacySettings(userID))
+}
+
+// UpdatePrivacySettings updates the current user's privacy settings
+func (ac *AuthController) UpdatePrivacySettings(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req struct {
+		ShowEmail            bool   `json:"show_email"`
+		LocationPrecision    string `json:"location_precision" binding:"required,oneof=exact city country hidden"`
+		ShowReviews          bool   `json:"show_reviews"`
+		ProfileVisibility    string `json:"profile_visibility" binding:"required,oneof=everyone matched nobody"`
+		PublicProfileEnabled bool   `json:"public_profile_enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profileService := &services.ProfileService{}
+	settings := profileService.GetPrivacySettings(userID)
+	settings.ShowEmail = req.ShowEmail
+	settings.LocationPrecision = req.LocationPrecision
+	settings.ShowReviews = req.ShowReviews
+	settings.ProfileVisibility = req.ProfileVisibility
+	settings.PublicProfileEnabled = req.PublicProfileEnabled
+
+	// Select all fields so false values are persisted too
+	if err := config.DB.Select("*").Save(&settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update privacy settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UploadAvatar stores a new avatar image for the current user
+func (ac *AuthController) UploadAvatar(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	limitUploadBody(c, config.AppConfig.AvatarMaxBytes)
+	header, err := c.FormFile("file")
+	if err != nil {
+		respondFormError(c, err, "A file is required")
+		return
+	}
+
+	uploadService := &services.UploadService{}
+	attachment, err := uploadService.SaveAvatar(userID, header)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	var user models.User
+	config.DB.First(&user, userID)
+
+	c.JSON(http.StatusOK, gin.H{"user": models.NewAccount(user), "file": attachment})
+}
+
+// ExportData returns everything stored about the current user as JSON or a ZIP archive
+func (ac *AuthController) ExportData(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	format := c.DefaultQuery("format", "json")
+
+	accountService := &services.AccountService{}
+	export, err := accountService.BuildExport(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export user data"})
+		return
+	}
+
+	switch format {
+	case "json":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=skillswap-export-%d.json", userID))
+		c.JSON(http.StatusOK, export)
+	case "zip":
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=skillswap-export-%d-%s.zip", userID, time.Now().Format("20060102")))
+		c.Status(http.StatusOK)
+		if err := accountService.WriteExportZip(c.Writer, export); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected json or zip"})
+	}
+}
+
+// DeleteAccount anonymizes and deletes the current user's account after confirming their password
+func (ac *AuthController) DeleteAccount(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	accountService := &services.AccountService{}
+	if err := accountService.DeleteAccount(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Account deleted successfully",
+		"retention_days": config.AppConfig.AccountRetentionDays,
+	})
 }
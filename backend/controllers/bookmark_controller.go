@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BookmarkController struct{}
+
+// BookmarkSkill adds a skill to the current user's bookmarks
+func (bc *BookmarkController) BookmarkSkill(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	skillID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+		return
+	}
+
+	var skill models.Skill
+	if err := config.DB.First(&skill, uint(skillID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Skill not found"})
+		return
+	}
+
+	bookmarkService := &services.BookmarkService{}
+	if err := bookmarkService.BookmarkSkill(userID, skill.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark skill"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Skill bookmarked", "skill_id": skill.ID, "is_bookmarked": true})
+}
+
+// RemoveBookmark removes a skill from the current user's bookmarks
+func (bc *BookmarkController) RemoveBookmark(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	skillID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+		return
+	}
+
+	bookmarkService := &services.BookmarkService{}
+	if err := bookmarkService.RemoveBookmark(userID, uint(skillID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed", "skill_id": skillID, "is_bookmarked": false})
+}
+
+// FollowUser follows another user
+func (bc *BookmarkController) FollowUser(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	followedID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, uint(followedID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	bookmarkService := &services.BookmarkService{}
+	if err := bookmarkService.Follow(userID, user.ID); err != nil {
+		if err == services.ErrCannotFollowSelf {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User followed", "user_id": user.ID, "is_following": true})
+}
+
+// UnfollowUser stops following another user
+func (bc *BookmarkController) UnfollowUser(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	followedID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	bookmarkService := &services.BookmarkService{}
+	if err := bookmarkService.Unfollow(userID, uint(followedID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unfollowed", "user_id": followedID, "is_following": false})
+}
+
+// GetBookmarks lists bookmarked skills (type=skills, default) or followed users (type=users)
+func (bc *BookmarkController) GetBookmarks(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	bookmarkType := c.DefaultQuery("type", "skills")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	var total int64
+	var items interface{}
+
+	switch bookmarkType {
+	case "skills":
+		query := config.DB.Model(&models.SkillBookmark{}).
+			Joins("JOIN skills ON skills.id = skill_bookmarks.skill_id AND skills.deleted_at IS NULL").
+			Where("skill_bookmarks.user_id = ?", userID)
+		query.Count(&total)
+
+		var bookmarks []models.SkillBookmark
+		if err := query.Preload("Skill").Preload("Skill.User").
+			Order("skill_bookmarks.created_at DESC").Limit(limit).Offset(offset).
+			Find(&bookmarks).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bookmarks"})
+			return
+		}
+
+		skills := make([]models.Skill, len(bookmarks))
+		for i, bookmark := range bookmarks {
+			skills[i] = bookmark.Skill
+		}
+		decorateSkills(skills, userID)
+		items = skills
+	case "users":
+		// Users whose profiles are hidden from the follower are left out before paginating
+		profileService := &services.ProfileService{}
+		query := profileService.ViewableBy(config.DB.Model(&models.UserFollow{}).
+			Joins("JOIN users ON users.id = user_follows.followed_id AND users.deleted_at IS NULL").
+			Where("user_follows.follower_id = ?", userID), "user_follows.followed_id", userID)
+		query.Count(&total)
+
+		var follows []models.UserFollow
+		if err := query.Preload("Followed").Order("user_follows.created_at DESC").Limit(limit).Offset(offset).
+			Find(&follows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch followed users"})
+			return
+		}
+
+		// Show followed users through the same privacy-aware profile as GET /api/user/:id
+		profiles := make([]models.PublicProfile, len(follows))
+		for i, follow := range follows {
+			settings := profileService.GetPrivacySettings(follow.FollowedID)
+			profiles[i] = profileService.BuildPublicProfile(follow.Followed, settings)
+		}
+		items = profiles
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type, expected skills or users"})
+		return
+	}
+
+	response := gin.H{
+		bookmarkType: items,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// decorateSkills fills computed skill fields (endorsements, bookmarks) for the viewer
+func decorateSkills(skills []models.Skill, viewerID uint) {
+	endorsementService := &services.EndorsementService{}
+	endorsementService.AttachEndorsements(skills)
+
+	bookmarkService := &services.BookmarkService{}
+	bookmarkService.AttachBookmarks(skills, viewerID)
+}
@@ -1,29 +1,46 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"skillswap-backend/config"
 	"skillswap-backend/models"
+	"skillswap-backend/services"
 	"skillswap-backend/utils"
 )
 
 type ChatController struct{}
 
-// GetChatRooms returns all chat rooms for the current user
+type CreateChatRoomRequest struct {
+	OtherUserID uint   `json:"other_user_id"`          // A direct room with this user
+	ExchangeID  uint   `json:"exchange_id"`            // The exchange's room
+	Name        string `json:"name" binding:"max=100"` // A group room with this name, and
+	MemberIDs   []uint `json:"member_ids"`             // these members
+	IsPublic    bool   `json:"is_public"`
+}
+
+type UpdateChatRoomRequest struct {
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	IsPublic *bool   `json:"is_public"`
+}
+
+// GetChatRooms returns all chat rooms the current user belongs to
 func (cc *ChatController) GetChatRooms(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
 
 	var chatRooms []models.ChatRoom
-	result := config.DB.Where("user1_id = ? OR user2_id = ?", userID, userID).
-		Preload("User1").
-		Preload("User2").
+	result := config.DB.Where("id IN (SELECT chat_room_id FROM chat_room_members WHERE user_id = ?)", userID).
+		Preload("Members", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Preload("Members.User").
 		Preload("Exchange").
-		Order("last_message_at DESC, created_at DESC").
+		Order("last_message_at DESC NULLS LAST, created_at DESC").
 		Find(&chatRooms)
 
 	if result.Error != nil {
@@ -31,108 +48,395 @@ func (cc *ChatController) GetChatRooms(c *gin.Context) {
 		return
 	}
 
-	// Process chat rooms to show the other user's info
-	var processedRooms []gin.H
+	processedRooms := make([]gin.H, 0, len(chatRooms))
 	for _, room := range chatRooms {
-		var otherUser models.User
-		if room.User1ID == userID {
-			otherUser = room.User2
-		} else {
-			otherUser = room.User1
+		processedRooms = append(processedRooms, cc.roomResponse(room, userID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"chat_rooms": processedRooms})
+}
+
+// CreateChatRoom opens a direct room with another user, an exchange's room, or a new group. Direct
+// and exchange rooms are reused if they already exist.
+func (cc *ChatController) CreateChatRoom(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req CreateChatRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chatService := &services.ChatService{}
+	var chatRoom models.ChatRoom
+	status := http.StatusOK
+
+	switch {
+	case req.ExchangeID != 0:
+		var exchange models.Exchange
+		if err := config.DB.Preload("Skill").First(&exchange, req.ExchangeID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Exchange not found"})
+			return
+		}
+		if exchange.RequesterID != userID && exchange.Skill.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have access to this exchange"})
+			return
+		}
+
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			chatRoom, err = chatService.ExchangeRoom(tx, exchange)
+			return err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chat room"})
+			return
+		}
+
+	case strings.TrimSpace(req.Name) != "":
+		memberIDs := req.MemberIDs
+		if req.OtherUserID != 0 {
+			memberIDs = append(memberIDs, req.OtherUserID)
+		}
+
+		// Create the group and the invitations together
+		notificationService := &services.NotificationService{}
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var added []uint
+			var err error
+			chatRoom, added, err = chatService.CreateGroupRoom(tx, userID, strings.TrimSpace(req.Name), req.IsPublic, memberIDs)
+			if err != nil {
+				return err
+			}
+			return notificationService.NotifyChatInvited(tx, chatRoom, userID, added)
+		}); err != nil {
+			respondChatMembershipError(c, err, "Failed to create chat room")
+			return
 		}
+		status = http.StatusCreated
 
-		processedRoom := gin.H{
-			"id":              room.ID,
-			"other_user":      otherUser,
-			"exchange_id":     room.ExchangeID,
-			"last_message":    room.LastMessage,
-			"last_message_at": room.LastMessageAt,
-			"created_at":      room.CreatedAt,
+	default:
+		if req.OtherUserID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "other_user_id, exchange_id or name is required"})
+			return
+		}
+		if req.OtherUserID == userID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "You can't start a chat with yourself"})
+			return
+		}
+		if err := config.DB.First(&models.User{}, req.OtherUserID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		var created bool
+		var err error
+		if chatRoom, created, err = chatService.DirectRoom(userID, req.OtherUserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chat room"})
+			return
+		}
+		if created {
+			status = http.StatusCreated
 		}
-		processedRooms = append(processedRooms, processedRoom)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"chat_rooms": processedRooms})
+	// Preload relationships
+	config.DB.Preload("Members.User").Preload("Exchange").First(&chatRoom, chatRoom.ID)
+
+	c.JSON(status, gin.H{"chat_room": chatRoom})
 }
 
-// CreateChatRoom creates a new chat room between two users
-func (cc *ChatController) CreateChatRoom(c *gin.Context) {
+// GetPublicChatRooms lists public groups the current user hasn't joined
+func (cc *ChatController) GetPublicChatRooms(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
 
-	var req struct {
-		OtherUserID uint `json:"other_user_id" binding:"required"`
-		ExchangeID  uint `json:"exchange_id,omitempty"`
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
 	}
+	offset := (page - 1) * limit
 
+	query := config.DB.Model(&models.ChatRoom{}).
+		Where("type = ? AND is_public = ? AND is_active = ?", services.ChatRoomGroup, true, true).
+		Where("id NOT IN (SELECT chat_room_id FROM chat_room_members WHERE user_id = ?)", userID)
+
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	var rooms []struct {
+		models.ChatRoom
+		MemberCount int64 `json:"member_count"`
+	}
+	var total int64
+
+	// Get total count
+	query.Count(&total)
+
+	// Get paginated results
+	if err := query.Select("chat_rooms.*, (SELECT COUNT(*) FROM chat_room_members WHERE chat_room_id = chat_rooms.id) AS member_count").
+		Order("last_message_at DESC NULLS LAST, created_at DESC").
+		Limit(limit).Offset(offset).Find(&rooms).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chat rooms"})
+		return
+	}
+
+	response := gin.H{
+		"chat_rooms": rooms,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateChatRoom renames a group or changes whether anyone can join it (owners only)
+func (cc *ChatController) UpdateChatRoom(c *gin.Context) {
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	if !cc.requireOwner(c, chatRoom, member) {
+		return
+	}
+
+	var req UpdateChatRoomRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check if chat room already exists
-	var existingRoom models.ChatRoom
-	result := config.DB.Where(
-		"(user1_id = ? AND user2_id = ?) OR (user1_id = ? AND user2_id = ?)",
-		userID, req.OtherUserID, req.OtherUserID, userID,
-	).First(&existingRoom)
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name can't be empty"})
+			return
+		}
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.IsPublic != nil {
+		updates["is_public"] = *req.IsPublic
+	}
 
-	if result.Error == nil {
-		// Chat room already exists
-		c.JSON(http.StatusOK, gin.H{"chat_room": existingRoom})
+	if len(updates) > 0 {
+		if err := config.DB.Model(&chatRoom).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update chat room"})
+			return
+		}
+		cc.pushToRoom(chatRoom, "room.updated", gin.H{"chat_room": chatRoom})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"chat_room": chatRoom})
+}
+
+// GetRoomMembers lists a room's members, owners and longest-standing first
+func (cc *ChatController) GetRoomMembers(c *gin.Context) {
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+
+	var members []models.ChatRoomMember
+	if err := config.DB.Where("chat_room_id = ?", chatRoom.ID).Preload("User").
+		Order("role = 'owner' DESC, created_at, id").Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch members"})
 		return
 	}
 
-	// Create new chat room
-	chatRoom := models.ChatRoom{
-		User1ID:  userID,
-		User2ID:  req.OtherUserID,
-		IsActive: true,
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// AddRoomMembers invites users to a group (owners only); they join straight away
+func (cc *ChatController) AddRoomMembers(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	if !cc.requireOwner(c, chatRoom, member) {
+		return
 	}
 
-	// Only set ExchangeID if it's not 0
-	if req.ExchangeID != 0 {
-		chatRoom.ExchangeID = &req.ExchangeID
-	} else {
-		chatRoom.ExchangeID = nil
+	var req struct {
+		UserIDs []uint `json:"user_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := config.DB.Create(&chatRoom).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chat room"})
+	// Add the members and their invitations together
+	chatService := &services.ChatService{}
+	notificationService := &services.NotificationService{}
+	var added []uint
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if added, err = chatService.AddMembers(tx, chatRoom, req.UserIDs); err != nil {
+			return err
+		}
+		return notificationService.NotifyChatInvited(tx, chatRoom, userID, added)
+	}); err != nil {
+		respondChatMembershipError(c, err, "Failed to add members")
 		return
 	}
 
-	// Preload relationships
-	config.DB.Preload("User1").Preload("User2").Preload("Exchange").First(&chatRoom, chatRoom.ID)
+	if len(added) > 0 {
+		cc.pushToRoom(chatRoom, "room.member_added", gin.H{"chat_room_id": chatRoom.ID, "user_ids": added, "added_by": userID})
+	}
 
-	c.JSON(http.StatusCreated, gin.H{"chat_room": chatRoom})
+	c.JSON(http.StatusOK, gin.H{"message": "Members added successfully", "added_user_ids": added})
 }
 
-// GetMessages returns all messages in a chat room
-func (cc *ChatController) GetMessages(c *gin.Context) {
+// UpdateRoomMember makes a member an owner or a plain member (owners only)
+func (cc *ChatController) UpdateRoomMember(c *gin.Context) {
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	if !cc.requireOwner(c, chatRoom, member) {
+		return
+	}
+
+	target, ok := cc.loadMember(c, chatRoom)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required,oneof=owner member"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chatService := &services.ChatService{}
+	if err := chatService.SetRole(chatRoom, target, req.Role); err != nil {
+		respondChatMembershipError(c, err, "Failed to update member")
+		return
+	}
+	target.Role = req.Role
+
+	cc.pushToRoom(chatRoom, "room.member_updated", gin.H{"chat_room_id": chatRoom.ID, "user_id": target.UserID, "role": target.Role})
+
+	c.JSON(http.StatusOK, gin.H{"member": target})
+}
+
+// RemoveRoomMember removes a member from a group (owners only; members can remove themselves)
+func (cc *ChatController) RemoveRoomMember(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+
+	target, ok := cc.loadMember(c, chatRoom)
+	if !ok {
+		return
+	}
+	if target.UserID != userID && !cc.requireOwner(c, chatRoom, member) {
+		return
+	}
+
+	cc.removeMember(c, chatRoom, target.UserID, userID)
+}
+
+// JoinChatRoom adds the current user to a public group
+func (cc *ChatController) JoinChatRoom(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
-	roomIDStr := c.Param("roomId")
-	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+	roomID, err := strconv.ParseUint(c.Param("roomId"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
 		return
 	}
 
-	// Verify user has access to this chat room
 	var chatRoom models.ChatRoom
-	result := config.DB.Where("id = ? AND (user1_id = ? OR user2_id = ?)", roomID, userID, userID).First(&chatRoom)
-	if result.Error != nil {
+	if err := config.DB.Where("id = ? AND type = ? AND is_public = ? AND is_active = ?", roomID, services.ChatRoomGroup, true, true).
+		First(&chatRoom).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Chat room not found"})
 		return
 	}
 
+	chatService := &services.ChatService{}
+	added, err := chatService.AddMembers(config.DB, chatRoom, []uint{userID})
+	if err != nil {
+		respondChatMembershipError(c, err, "Failed to join chat room")
+		return
+	}
+
+	if len(added) > 0 {
+		cc.pushToRoom(chatRoom, "room.member_added", gin.H{"chat_room_id": chatRoom.ID, "user_ids": added, "added_by": userID})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Joined chat room successfully"})
+}
+
+// LeaveChatRoom removes the current user from a group
+func (cc *ChatController) LeaveChatRoom(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+
+	cc.removeMember(c, chatRoom, userID, userID)
+}
+
+func (cc *ChatController) removeMember(c *gin.Context, chatRoom models.ChatRoom, userID, removedBy uint) {
+	// Tell the room before the member loses access, so their other sessions hear it too
+	chatService := &services.ChatService{}
+	memberIDs, _ := chatService.MemberIDs(config.DB, chatRoom.ID)
+
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return chatService.RemoveMember(tx, chatRoom, userID)
+	}); err != nil {
+		respondChatMembershipError(c, err, "Failed to remove member")
+		return
+	}
+
+	event := gin.H{"chat_room_id": chatRoom.ID, "user_id": userID, "removed_by": removedBy}
+	for _, memberID := range memberIDs {
+		services.Push().Publish(memberID, "room.member_removed", event)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// GetMessages returns all messages in a chat room
+func (cc *ChatController) GetMessages(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	// Verify user is a member of this chat room
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+
 	// Get pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
 	offset := (page - 1) * limit
 
 	var messages []models.Message
-	result = config.DB.Where("chat_room_id = ?", roomID).
+	result := config.DB.Where("chat_room_id = ?", chatRoom.ID).
 		Preload("Sender").
+		Preload("Attachment").
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -148,6 +452,11 @@ func (cc *ChatController) GetMessages(c *gin.Context) {
 		messages[i], messages[j] = messages[j], messages[i]
 	}
 
+	chatService := &services.ChatService{}
+	chatService.AttachReactions(messages, userID)
+	chatService.AttachThreads(messages)
+	chatService.AttachReadState(chatRoom, member, messages)
+
 	c.JSON(http.StatusOK, gin.H{
 		"messages": messages,
 		"pagination": gin.H{
@@ -161,16 +470,11 @@ func (cc *ChatController) GetMessages(c *gin.Context) {
 // SendMessage sends a new message to a chat room
 func (cc *ChatController) SendMessage(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
-	roomIDStr := c.Param("roomId")
-	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
-		return
-	}
 
 	var req struct {
 		Content     string `json:"content" binding:"required"`
 		MessageType string `json:"message_type"`
+		ParentID    *uint  `json:"parent_id"` // Reply to this message
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -178,11 +482,12 @@ func (cc *ChatController) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// Verify user has access to this chat room
-	var chatRoom models.ChatRoom
-	result := config.DB.Where("id = ? AND (user1_id = ? OR user2_id = ?)", roomID, userID, userID).First(&chatRoom)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chat room not found"})
+	// Verify user is a member of this chat room
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	if !cc.validateParent(c, chatRoom, req.ParentID) {
 		return
 	}
 
@@ -193,14 +498,22 @@ func (cc *ChatController) SendMessage(c *gin.Context) {
 
 	// Create message
 	message := models.Message{
-		ChatRoomID:  uint(roomID),
+		ChatRoomID:  chatRoom.ID,
 		SenderID:    userID,
 		Content:     req.Content,
 		MessageType: req.MessageType,
+		ParentID:    req.ParentID,
 		IsRead:      false,
 	}
 
-	if err := config.DB.Create(&message).Error; err != nil {
+	// Create the message and the other members' notifications together
+	notificationService := &services.NotificationService{}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		return notificationService.NotifyMessageCreated(tx, message, chatRoom)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
 		return
 	}
@@ -212,62 +525,396 @@ func (cc *ChatController) SendMessage(c *gin.Context) {
 		LastMessageAt: &now,
 	})
 
-	// Preload sender info
+	// Preload sender info and the quoted message
 	config.DB.Preload("Sender").First(&message, message.ID)
+	cc.attachThreads(&message)
+
+	cc.pushToRoom(chatRoom, "message.created", gin.H{"message": message})
 
 	c.JSON(http.StatusCreated, gin.H{"message": message})
 }
 
-// MarkMessagesAsRead marks messages as read
-func (cc *ChatController) MarkMessagesAsRead(c *gin.Context) {
+// UploadAttachment sends an image or file message to a chat room
+func (cc *ChatController) UploadAttachment(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
-	roomIDStr := c.Param("roomId")
-	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
+
+	// Verify user is a member of this chat room
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+
+	limitUploadBody(c, config.AppConfig.ChatAttachmentMaxBytes)
+	header, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		respondFormError(c, err, "A file is required")
 		return
 	}
 
-	// Verify user has access to this chat room
-	var chatRoom models.ChatRoom
-	result := config.DB.Where("id = ? AND (user1_id = ? OR user2_id = ?)", roomID, userID, userID).First(&chatRoom)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chat room not found"})
+	// Optional message to reply to
+	var parentID *uint
+	if raw := c.PostForm("parent_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent message ID"})
+			return
+		}
+		parent := uint(id)
+		parentID = &parent
+	}
+	if !cc.validateParent(c, chatRoom, parentID) {
 		return
 	}
 
-	// Mark messages as read (messages not sent by current user)
+	uploadService := &services.UploadService{}
+	attachment, err := uploadService.SaveChatAttachment(userID, chatRoom.ID, header)
+	if err != nil {
+		respondUploadError(c, err)
+		return
+	}
+
+	messageType := "file"
+	if uploadService.IsImage(*attachment) {
+		messageType = "image"
+	}
+
+	// Optional caption, otherwise the file name
+	content := c.PostForm("content")
+	if content == "" {
+		content = attachment.FileName
+	}
+
+	message := models.Message{
+		ChatRoomID:   chatRoom.ID,
+		SenderID:     userID,
+		Content:      content,
+		MessageType:  messageType,
+		AttachmentID: &attachment.ID,
+		ParentID:     parentID,
+		IsRead:       false,
+	}
+
+	// Create the message and the other members' notifications together
+	notificationService := &services.NotificationService{}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		return notificationService.NotifyMessageCreated(tx, message, chatRoom)
+	}); err != nil {
+		uploadService.DeleteAttachment(*attachment)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
+		return
+	}
+
+	// Update chat room last message
 	now := time.Now()
-	result = config.DB.Model(&models.Message{}).
-		Where("chat_room_id = ? AND sender_id != ? AND is_read = false", roomID, userID).
-		Updates(map[string]interface{}{
-			"is_read": true,
-			"read_at": now,
-		})
+	config.DB.Model(&chatRoom).Updates(models.ChatRoom{
+		LastMessage:   content,
+		LastMessageAt: &now,
+	})
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages as read"})
+	// Preload sender and attachment info, and the quoted message
+	config.DB.Preload("Sender").Preload("Attachment").First(&message, message.ID)
+	cc.attachThreads(&message)
+
+	cc.pushToRoom(chatRoom, "message.created", gin.H{"message": message})
+
+	c.JSON(http.StatusCreated, gin.H{"message": message})
+}
+
+// GetThread returns a message and its replies, oldest first
+func (cc *ChatController) GetThread(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	parent, ok := cc.loadMessage(c, chatRoom)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	// Replies deleted for everyone are left out, as they are from reply_count
+	query := config.DB.Model(&models.Message{}).Where("parent_id = ? AND retracted_at IS NULL", parent.ID)
+
+	var replies []models.Message
+	var total int64
+
+	// Get total count
+	query.Count(&total)
+
+	// Get paginated results
+	if err := query.Preload("Sender").Preload("Attachment").
+		Order("created_at ASC, id ASC").
+		Limit(limit).Offset(offset).
+		Find(&replies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch thread"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Messages marked as read", "updated_count": result.RowsAffected})
+	config.DB.Preload("Sender").Preload("Attachment").First(&parent, parent.ID)
+	messages := append([]models.Message{parent}, replies...)
+
+	chatService := &services.ChatService{}
+	chatService.AttachReactions(messages, userID)
+	chatService.AttachThreads(messages)
+	chatService.AttachReadState(chatRoom, member, messages)
+
+	c.JSON(http.StatusOK, gin.H{
+		"parent":  messages[0],
+		"replies": messages[1:],
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	})
 }
 
-// DeleteChatRoom soft deletes a chat room
-func (cc *ChatController) DeleteChatRoom(c *gin.Context) {
+// EditMessage changes the content of one of the current user's messages
+func (cc *ChatController) EditMessage(c *gin.Context) {
 	userID := utils.GetUserIDFromContext(c)
-	roomIDStr := c.Param("roomId")
-	roomID, err := strconv.ParseUint(roomIDStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Verify user has access to this chat room
-	var chatRoom models.ChatRoom
-	result := config.DB.Where("id = ? AND (user1_id = ? OR user2_id = ?)", roomID, userID, userID).First(&chatRoom)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chat room not found"})
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	message, ok := cc.loadMessage(c, chatRoom)
+	if !ok {
+		return
+	}
+	if message.SenderID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own messages"})
+		return
+	}
+
+	chatService := &services.ChatService{}
+	if err := chatService.EditMessage(&message, req.Content); err != nil {
+		respondMessageError(c, err, "Failed to edit message")
+		return
+	}
+
+	config.DB.Preload("Sender").Preload("Attachment").First(&message, message.ID)
+	messages := []models.Message{message}
+	chatService.AttachReactions(messages, userID)
+	chatService.AttachThreads(messages)
+	message = messages[0]
+
+	cc.pushToRoom(chatRoom, "message.updated", gin.H{"message": message})
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// GetMessageEdits returns a message's earlier versions, oldest first
+func (cc *ChatController) GetMessageEdits(c *gin.Context) {
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	message, ok := cc.loadMessage(c, chatRoom)
+	if !ok {
+		return
+	}
+
+	var edits []models.MessageEdit
+	if err := config.DB.Where("message_id = ?", message.ID).Order("created_at, id").Find(&edits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch edit history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "edits": edits})
+}
+
+// DeleteMessage deletes one of the current user's messages for everyone, leaving a tombstone
+func (cc *ChatController) DeleteMessage(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	message, ok := cc.loadMessage(c, chatRoom)
+	if !ok {
+		return
+	}
+	if message.SenderID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own messages"})
+		return
+	}
+
+	chatService := &services.ChatService{}
+	if err := chatService.RetractMessage(&message); err != nil {
+		respondMessageError(c, err, "Failed to delete message")
+		return
+	}
+
+	cc.pushToRoom(chatRoom, "message.deleted", gin.H{"chat_room_id": chatRoom.ID, "message_id": message.ID, "retracted_at": message.RetractedAt})
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// AddReaction reacts to a message with an emoji
+func (cc *ChatController) AddReaction(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req struct {
+		Emoji string `json:"emoji" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	message, ok := cc.loadMessage(c, chatRoom)
+	if !ok {
+		return
+	}
+
+	chatService := &services.ChatService{}
+	if err := chatService.AddReaction(message, userID, req.Emoji); err != nil {
+		respondMessageError(c, err, "Failed to add reaction")
+		return
+	}
+
+	cc.respondReactions(c, chatRoom, message, userID, req.Emoji, "added")
+}
+
+// RemoveReaction takes back the current user's reaction with an emoji
+func (cc *ChatController) RemoveReaction(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	chatRoom, _, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	message, ok := cc.loadMessage(c, chatRoom)
+	if !ok {
+		return
+	}
+
+	emoji := c.Param("emoji")
+	chatService := &services.ChatService{}
+	if err := chatService.RemoveReaction(message, userID, emoji); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reaction not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
+		return
+	}
+
+	cc.respondReactions(c, chatRoom, message, userID, emoji, "removed")
+}
+
+// respondReactions tells the room about a reaction change and returns the message's reactions
+func (cc *ChatController) respondReactions(c *gin.Context, chatRoom models.ChatRoom, message models.Message, userID uint, emoji, action string) {
+	chatService := &services.ChatService{}
+	messages := []models.Message{message}
+	chatService.AttachReactions(messages, userID)
+
+	// Counts are the same for everyone; whether they reacted isn't
+	var count int64
+	for _, reaction := range messages[0].Reactions {
+		if reaction.Emoji == emoji {
+			count = reaction.Count
+		}
+	}
+	cc.pushToRoom(chatRoom, "message.reaction", gin.H{
+		"chat_room_id": chatRoom.ID,
+		"message_id":   message.ID,
+		"user_id":      userID,
+		"emoji":        emoji,
+		"action":       action,
+		"count":        count,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message_id": message.ID, "reactions": messages[0].Reactions})
+}
+
+// MarkMessagesAsRead marks messages as read
+func (cc *ChatController) MarkMessagesAsRead(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	// Verify user is a member of this chat room
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+
+	// Whether anyone wrote since this member last read the room, for read receipts in groups
+	var newMessages int64
+	newQuery := config.DB.Model(&models.Message{}).Where("chat_room_id = ? AND sender_id != ?", chatRoom.ID, userID)
+	if member.LastReadAt != nil {
+		newQuery = newQuery.Where("created_at > ?", *member.LastReadAt)
+	}
+	newQuery.Count(&newMessages)
+
+	// Mark messages as read (messages not sent by current user). Groups only track it per member,
+	// through last_read_at, so one member reading doesn't mark messages read for everyone.
+	now := time.Now()
+	updatedCount := newMessages
+	if chatRoom.Type != services.ChatRoomGroup {
+		result := config.DB.Model(&models.Message{}).
+			Where("chat_room_id = ? AND sender_id != ? AND is_read = false", chatRoom.ID, userID).
+			Updates(map[string]interface{}{
+				"is_read": true,
+				"read_at": now,
+			})
+
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages as read"})
+			return
+		}
+		updatedCount = result.RowsAffected
+	}
+
+	chatService := &services.ChatService{}
+	if err := chatService.MarkRead(member, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages as read"})
+		return
+	}
+
+	// Show read receipts to the senders
+	if updatedCount > 0 || newMessages > 0 {
+		cc.pushToRoom(chatRoom, "message.read", gin.H{"chat_room_id": chatRoom.ID, "reader_id": userID, "read_at": now})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Messages marked as read", "updated_count": updatedCount})
+}
+
+// DeleteChatRoom soft deletes a chat room for all its members (groups: owners only)
+func (cc *ChatController) DeleteChatRoom(c *gin.Context) {
+	// Verify user is a member of this chat room
+	chatRoom, member, ok := cc.loadRoom(c)
+	if !ok {
+		return
+	}
+	if chatRoom.Type == services.ChatRoomGroup && !cc.requireOwner(c, chatRoom, member) {
 		return
 	}
 
@@ -277,5 +924,167 @@ func (cc *ChatController) DeleteChatRoom(c *gin.Context) {
 		return
 	}
 
+	cc.pushToRoom(chatRoom, "room.deleted", gin.H{"chat_room_id": chatRoom.ID})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Chat room deleted successfully"})
 }
+
+// loadRoom loads the chat room in the URL if the current user is a member
+func (cc *ChatController) loadRoom(c *gin.Context) (models.ChatRoom, models.ChatRoomMember, bool) {
+	userID := utils.GetUserIDFromContext(c)
+	var chatRoom models.ChatRoom
+
+	roomID, err := strconv.ParseUint(c.Param("roomId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		return chatRoom, models.ChatRoomMember{}, false
+	}
+
+	chatService := &services.ChatService{}
+	member, err := chatService.Membership(uint(roomID), userID)
+	if err == nil {
+		err = config.DB.First(&chatRoom, roomID).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat room not found"})
+		return chatRoom, member, false
+	}
+
+	return chatRoom, member, true
+}
+
+// loadMessage loads the message in the URL from the given room
+func (cc *ChatController) loadMessage(c *gin.Context, chatRoom models.ChatRoom) (models.Message, bool) {
+	var message models.Message
+
+	messageID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return message, false
+	}
+
+	if err := config.DB.Where("id = ? AND chat_room_id = ?", messageID, chatRoom.ID).First(&message).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return message, false
+	}
+
+	return message, true
+}
+
+// validateParent checks the message a reply is to, if any
+func (cc *ChatController) validateParent(c *gin.Context, chatRoom models.ChatRoom, parentID *uint) bool {
+	if parentID == nil {
+		return true
+	}
+
+	chatService := &services.ChatService{}
+	if err := chatService.ValidateParent(chatRoom.ID, *parentID); err != nil {
+		respondMessageError(c, err, "Failed to send message")
+		return false
+	}
+	return true
+}
+
+// attachThreads fills a single message's reply count and quote
+func (cc *ChatController) attachThreads(message *models.Message) {
+	chatService := &services.ChatService{}
+	messages := []models.Message{*message}
+	chatService.AttachThreads(messages)
+	*message = messages[0]
+}
+
+// loadMember loads the membership of the user in the URL
+func (cc *ChatController) loadMember(c *gin.Context, chatRoom models.ChatRoom) (models.ChatRoomMember, bool) {
+	var member models.ChatRoomMember
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return member, false
+	}
+
+	if err := config.DB.Where("chat_room_id = ? AND user_id = ?", chatRoom.ID, userID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return member, false
+	}
+
+	return member, true
+}
+
+// requireOwner responds with an error unless the room is a group the member owns
+func (cc *ChatController) requireOwner(c *gin.Context, chatRoom models.ChatRoom, member models.ChatRoomMember) bool {
+	if chatRoom.Type != services.ChatRoomGroup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only group rooms can be managed"})
+		return false
+	}
+	if member.Role != services.ChatRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only room owners can do this"})
+		return false
+	}
+	return true
+}
+
+// roomResponse describes a room from a member's point of view. Two-person rooms also get the other
+// member as other_user.
+func (cc *ChatController) roomResponse(room models.ChatRoom, userID uint) gin.H {
+	response := gin.H{
+		"id":              room.ID,
+		"type":            room.Type,
+		"name":            room.Name,
+		"is_public":       room.IsPublic,
+		"is_active":       room.IsActive,
+		"exchange_id":     room.ExchangeID,
+		"members":         room.Members,
+		"last_message":    room.LastMessage,
+		"last_message_at": room.LastMessageAt,
+		"created_at":      room.CreatedAt,
+	}
+
+	for _, member := range room.Members {
+		if member.UserID == userID {
+			response["role"] = member.Role
+			response["last_read_at"] = member.LastReadAt
+		} else if room.Type != services.ChatRoomGroup {
+			response["other_user"] = member.User
+		}
+	}
+
+	return response
+}
+
+// pushToRoom sends a live event to every member, including the sender's other sessions
+func (cc *ChatController) pushToRoom(chatRoom models.ChatRoom, eventType string, data interface{}) {
+	chatService := &services.ChatService{}
+	memberIDs, err := chatService.MemberIDs(config.DB, chatRoom.ID)
+	if err != nil {
+		return
+	}
+	for _, memberID := range memberIDs {
+		services.Push().Publish(memberID, eventType, data)
+	}
+}
+
+// respondChatMembershipError maps membership changes that failed to HTTP responses
+func respondChatMembershipError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrChatRoomFull), errors.Is(err, services.ErrNotGroupRoom), errors.Is(err, services.ErrLastChatOwner):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrChatUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// respondMessageError maps message changes that failed to HTTP responses
+func respondMessageError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrMessageNotEditable), errors.Is(err, services.ErrInvalidReaction),
+		errors.Is(err, services.ErrInvalidParent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrDeleteWindowPassed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
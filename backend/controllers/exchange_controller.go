@@ -63,7 +63,14 @@ func (ec *ExchangeController) CreateExchange(c *gin.Context) {
 		Status:      "pending",
 	}
 
-	if err := config.DB.Create(&exchange).Error; err != nil {
+	// Create the exchange and the skill owner's notifications together
+	notificationService := &services.NotificationService{}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&exchange).Error; err != nil {
+			return err
+		}
+		return notificationService.NotifyExchangeRequested(tx, exchange)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exchange request"})
 		return
 	}
@@ -71,12 +78,6 @@ func (ec *ExchangeController) CreateExchange(c *gin.Context) {
 	// Load relationships
 	config.DB.Preload("Requester").Preload("Skill").Preload("Skill.User").First(&exchange, exchange.ID)
 
-	// Send email notification to skill owner
-	go func() {
-		emailService := &services.EmailService{}
-		emailService.SendExchangeRequestNotification(exchange)
-	}()
-
 	c.JSON(http.StatusCreated, exchange)
 }
 
@@ -190,7 +191,21 @@ func (ec *ExchangeController) UpdateExchangeStatus(c *gin.Context) {
 	exchange.Status = req.Status
 	exchange.ResponseText = req.ResponseText
 
-	if err := config.DB.Save(&exchange).Error; err != nil {
+	// Save the status, the exchange's chat room and the requester's notifications together
+	notificationService := &services.NotificationService{}
+	chatService := &services.ChatService{}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&exchange).Error; err != nil {
+			return err
+		}
+		// Accepted exchanges get a room for both parties to plan their sessions
+		if exchange.Status == "accepted" {
+			if _, err := chatService.ExchangeRoom(tx, exchange); err != nil {
+				return err
+			}
+		}
+		return notificationService.NotifyExchangeStatusChanged(tx, exchange)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exchange status"})
 		return
 	}
@@ -198,11 +213,5 @@ func (ec *ExchangeController) UpdateExchangeStatus(c *gin.Context) {
 	// Load relationships
 	config.DB.Preload("Requester").Preload("Skill").Preload("Skill.User").First(&exchange, exchange.ID)
 
-	// Send email notification to requester about status change
-	go func() {
-		emailService := &services.EmailService{}
-		emailService.SendExchangeStatusUpdateNotification(exchange)
-	}()
-
 	c.JSON(http.StatusOK, exchange)
 }
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExperimentController struct{}
+
+type ExperimentVariantRequest struct {
+	Name    string          `json:"name" binding:"required,max=50"`
+	Weight  int             `json:"weight" binding:"required,min=1"`
+	Scoring json.RawMessage `json:"scoring"` // Overrides of the default match scoring, e.g. {"mutual_interest_bonus": 0}
+}
+
+type CreateExperimentRequest struct {
+	Key            string                     `json:"key" binding:"required,max=100"`
+	Description    string                     `json:"description"`
+	TrafficPercent *int                       `json:"traffic_percent" binding:"omitempty,min=0,max=100"`
+	Variants       []ExperimentVariantRequest `json:"variants" binding:"required,min=2,dive"`
+}
+
+type UpdateExperimentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=running stopped"`
+}
+
+// GetExperiments lists matching experiments with their variants
+func (ec *ExperimentController) GetExperiments(c *gin.Context) {
+	var experiments []models.Experiment
+	if err := config.DB.Preload("Variants").Order("created_at DESC").Find(&experiments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch experiments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"experiments": experiments})
+}
+
+// CreateExperiment creates a draft experiment; start it with UpdateExperimentStatus
+func (ec *ExperimentController) CreateExperiment(c *gin.Context) {
+	var req CreateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	experiment := models.Experiment{
+		Key:            req.Key,
+		Description:    req.Description,
+		Status:         "draft",
+		TrafficPercent: 100,
+	}
+	if req.TrafficPercent != nil {
+		experiment.TrafficPercent = *req.TrafficPercent
+	}
+
+	names := make(map[string]bool)
+	for _, variantReq := range req.Variants {
+		if names[variantReq.Name] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Variant names must be unique"})
+			return
+		}
+		names[variantReq.Name] = true
+
+		// Reject overrides that wouldn't parse at serving time
+		if _, err := services.ParseMatchScoringConfig(variantReq.Name, variantReq.Scoring); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		experiment.Variants = append(experiment.Variants, models.ExperimentVariant{
+			Name:    variantReq.Name,
+			Weight:  variantReq.Weight,
+			Scoring: string(variantReq.Scoring),
+		})
+	}
+
+	var existing int64
+	config.DB.Model(&models.Experiment{}).Where("key = ?", experiment.Key).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An experiment with this key already exists"})
+		return
+	}
+
+	// Select all fields so a 0% traffic allocation isn't replaced by the column default
+	if err := config.DB.Select("*").Create(&experiment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create experiment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, experiment)
+}
+
+// UpdateExperimentStatus starts or stops an experiment
+func (ec *ExperimentController) UpdateExperimentStatus(c *gin.Context) {
+	experiment, ok := ec.loadExperiment(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateExperimentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	experimentService := &services.ExperimentService{}
+	if err := experimentService.SetStatus(&experiment, req.Status); err != nil {
+		if err == services.ErrExperimentAlreadyRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update experiment status"})
+		return
+	}
+
+	config.DB.Preload("Variants").First(&experiment, experiment.ID)
+	c.JSON(http.StatusOK, experiment)
+}
+
+// GetExperimentMetrics aggregates exchange requests and acceptance per variant
+func (ec *ExperimentController) GetExperimentMetrics(c *gin.Context) {
+	experiment, ok := ec.loadExperiment(c)
+	if !ok {
+		return
+	}
+
+	experimentService := &services.ExperimentService{}
+	metrics, err := experimentService.Metrics(experiment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute experiment metrics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"experiment": experiment, "variants": metrics})
+}
+
+func (ec *ExperimentController) loadExperiment(c *gin.Context) (models.Experiment, bool) {
+	var experiment models.Experiment
+
+	experimentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid experiment ID"})
+		return experiment, false
+	}
+
+	if err := config.DB.Preload("Variants").First(&experiment, uint(experimentID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Experiment not found"})
+		return experiment, false
+	}
+
+	return experiment, true
+}
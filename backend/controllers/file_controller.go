@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileController struct{}
+
+// GetFile returns attachment metadata with download URLs the current user may use
+func (fc *FileController) GetFile(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	var attachment models.Attachment
+	if err := config.DB.First(&attachment, uint(fileID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	uploadService := &services.UploadService{}
+
+	// Avatars are public; portfolio files are for logged-in users; chat files are only for room participants
+	if attachment.Purpose == "avatar" {
+		response := gin.H{"file": attachment, "url": uploadService.ContentPath(attachment.ID, "")}
+		if attachment.HasThumbnail {
+			response["thumbnail_url"] = uploadService.ContentPath(attachment.ID, "thumbnail")
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if !fc.canAccessFile(userID, attachment) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	fileURL, expiresAt := uploadService.SignedURL(attachment.ID, "")
+	response := gin.H{"file": attachment, "url": fileURL, "expires_at": expiresAt}
+	if attachment.HasThumbnail {
+		thumbnailURL, _ := uploadService.SignedURL(attachment.ID, "thumbnail")
+		response["thumbnail_url"] = thumbnailURL
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DownloadFile streams a file. Chat files require a valid signature from GetFile.
+func (fc *FileController) DownloadFile(c *gin.Context) {
+	fileID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+
+	variant := c.Query("variant")
+	if variant != "" && variant != "thumbnail" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid variant"})
+		return
+	}
+
+	var attachment models.Attachment
+	if err := config.DB.First(&attachment, uint(fileID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	uploadService := &services.UploadService{}
+	if attachment.Purpose != "avatar" &&
+		!uploadService.VerifySignedURL(attachment.ID, variant, c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired download link"})
+		return
+	}
+
+	key := attachment.StorageKey
+	contentType := attachment.ContentType
+	if variant == "thumbnail" {
+		if !attachment.HasThumbnail {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not available"})
+			return
+		}
+		key = attachment.ThumbnailKey
+		contentType = "image/jpeg"
+		if attachment.ContentType == "image/png" || attachment.ContentType == "image/gif" {
+			contentType = "image/png"
+		}
+	}
+
+	reader, err := services.FileStorage.Get(key)
+	if err != nil {
+		if err == services.ErrObjectNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer reader.Close()
+
+	disposition := "attachment"
+	if uploadService.IsImage(attachment) {
+		disposition = "inline"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, attachment.FileName))
+	c.Header("X-Content-Type-Options", "nosniff")
+	if attachment.Purpose == "avatar" {
+		c.Header("Cache-Control", "public, max-age=86400")
+	} else {
+		c.Header("Cache-Control", "private, no-store")
+	}
+	if variant == "" {
+		c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	}
+
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, reader)
+}
+
+func (fc *FileController) canAccessFile(userID uint, attachment models.Attachment) bool {
+	// Portfolio evidence is shown to anyone browsing skills
+	if attachment.Purpose == "portfolio" {
+		return true
+	}
+
+	if attachment.ChatRoomID == nil {
+		return attachment.OwnerID == userID
+	}
+
+	chatService := &services.ChatService{}
+	return chatService.IsMember(*attachment.ChatRoomID, userID)
+}
+
+// uploadFormOverhead leaves room for multipart boundaries, part headers and the other form fields
+const uploadFormOverhead = 1 << 20
+
+// limitUploadBody caps the request body at an upload's maximum size, so an oversized upload is cut
+// off while it's read rather than parsed and spooled to disk before the size check
+func limitUploadBody(c *gin.Context, maxBytes int) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxBytes)+uploadFormOverhead)
+}
+
+// respondFormError responds to a failure to read an upload form
+func respondFormError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondUploadError(c, services.ErrFileTooLarge)
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": message})
+}
+
+// respondUploadError maps upload failures to HTTP responses
+func respondUploadError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrFileTooLarge:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large"})
+	case services.ErrUnsupportedFileType:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported file type"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+	}
+}
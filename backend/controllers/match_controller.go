@@ -2,44 +2,231 @@ package controllers
 
 import (
 	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
 	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// Snooze length when the request doesn't specify one
+	defaultSnoozeDays = 7
+
+	maxMatchPageSize = 100
+)
+
 type MatchController struct{}
 
 func (mc *MatchController) GetMatches(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	page, response, ok := mc.findMatchPage(c, "/api/matches", false)
+	if !ok {
 		return
 	}
 
-	matchService := &services.MatchService{}
-	matches, err := matchService.FindMatches(userID.(uint))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find matches"})
-		return
+	// Convert to basic Match format for compatibility
+	matches := make([]models.Match, len(page))
+	for i, am := range page {
+		matches[i] = am.Match
 	}
 
-	c.JSON(http.StatusOK, gin.H{"matches": matches})
+	response["matches"] = matches
+	c.JSON(http.StatusOK, response)
 }
 
 // GetAdvancedMatches returns enhanced matches with additional scoring factors
 func (mc *MatchController) GetAdvancedMatches(c *gin.Context) {
+	page, response, ok := mc.findMatchPage(c, "/api/matches/advanced", false)
+	if !ok {
+		return
+	}
+
+	response["matches"] = page
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLearners returns users seeking skills the current user offers, optionally for one offered skill
+func (mc *MatchController) GetLearners(c *gin.Context) {
+	page, response, ok := mc.findMatchPage(c, "/api/matches/learners", true)
+	if !ok {
+		return
+	}
+
+	response["learners"] = page
+	c.JSON(http.StatusOK, response)
+}
+
+// findMatchPage computes, filters, sorts and pages the current user's matches (or learners). It
+// returns the page and a response body with pagination (and experiment) fields, or writes an error.
+func (mc *MatchController) findMatchPage(c *gin.Context, endpoint string, learners bool) ([]services.AdvancedMatch, gin.H, bool) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, nil, false
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > maxMatchPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return nil, nil, false
+	}
+
+	minScore, err := strconv.Atoi(c.DefaultQuery("min_score", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_score"})
+		return nil, nil, false
+	}
+
+	sortBy := c.DefaultQuery("sort", "score")
+	if !services.IsValidMatchSort(sortBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort, expected one of score, rating, activity, completion, location"})
+		return nil, nil, false
+	}
+
+	filters := services.MatchFilters{
+		MinScore:   minScore,
+		MutualOnly: c.Query("mutual_only") == "true",
+		Location:   c.Query("location"),
+	}
+
+	// For learners, category and level describe what they seek; skill_id picks one of my offers
+	if learners {
+		filters.SeekingCategory = c.Query("category")
+		filters.SeekingLevel = c.Query("level")
+
+		if skillIDStr := c.Query("skill_id"); skillIDStr != "" {
+			skillID, err := strconv.ParseUint(skillIDStr, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+				return nil, nil, false
+			}
+
+			var skill models.Skill
+			if err := config.DB.Where("id = ? AND user_id = ? AND skill_type = ?", skillID, userID, "offering").First(&skill).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Offered skill not found"})
+				return nil, nil, false
+			}
+			filters.OfferedSkillID = skill.ID
+		}
+	} else {
+		filters.Category = c.Query("category")
+		filters.Level = c.Query("level")
+	}
+
+	// Users in a running experiment get their variant's pipeline
+	experimentService := &services.ExperimentService{}
+	matchService, assignment := experimentService.MatchServiceFor(userID.(uint))
+	var matches []services.AdvancedMatch
+	if learners {
+		matches, err = matchService.FindLearners(userID.(uint))
+	} else {
+		matches, err = matchService.FindAdvancedMatches(userID.(uint))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find matches"})
+		return nil, nil, false
+	}
+
+	matches = filters.Apply(matches)
+	services.SortMatches(matches, sortBy)
+
+	page, nextCursor, err := services.PageMatches(matches, sortBy, c.Query("cursor"), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return nil, nil, false
+	}
+
+	response := gin.H{
+		"pagination": gin.H{
+			"next_cursor": nextCursor,
+			"has_more":    nextCursor != "",
+			"total_items": len(matches),
+			"per_page":    limit,
+		},
+	}
+	if assignment != nil {
+		experimentService.LogExposure(*assignment, userID.(uint), endpoint, len(page))
+		response["experiment"] = assignment
+	}
+
+	return page, response, true
+}
+
+type MatchFeedbackRequest struct {
+	OfferedSkillID uint   `json:"offered_skill_id" binding:"required"`
+	Action         string `json:"action" binding:"required,oneof=dismiss snooze like"`
+	SnoozeDays     int    `json:"snooze_days" binding:"omitempty,min=1,max=365"`
+}
+
+// SubmitFeedback dismisses, snoozes or likes a suggested match
+func (mc *MatchController) SubmitFeedback(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req MatchFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var skill models.Skill
+	if err := config.DB.First(&skill, req.OfferedSkillID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Skill not found"})
+		return
+	}
+
+	if skill.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot give match feedback on your own skill"})
 		return
 	}
 
-	matchService := &services.MatchService{}
-	matches, err := matchService.FindAdvancedMatches(userID.(uint))
+	if req.Action == "snooze" && req.SnoozeDays == 0 {
+		req.SnoozeDays = defaultSnoozeDays
+	}
+
+	matchFeedbackService := &services.MatchFeedbackService{}
+	feedback, err := matchFeedbackService.SetFeedback(userID, skill, req.Action, req.SnoozeDays)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find advanced matches"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save match feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, feedback)
+}
+
+// GetFeedback lists the current user's match feedback
+func (mc *MatchController) GetFeedback(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	query := config.DB.Preload("OfferedSkill").Where("user_id = ?", userID)
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var feedback []models.MatchFeedback
+	if err := query.Order("updated_at DESC").Find(&feedback).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch match feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feedback": feedback})
+}
+
+// DeleteFeedback undoes feedback so the skill can be suggested again
+func (mc *MatchController) DeleteFeedback(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	skillID, err := strconv.ParseUint(c.Param("skillId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+		return
+	}
+
+	matchFeedbackService := &services.MatchFeedbackService{}
+	if err := matchFeedbackService.RemoveFeedback(userID, uint(skillID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove match feedback"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"matches": matches})
+	c.JSON(http.StatusOK, gin.H{"message": "Match feedback removed"})
 }
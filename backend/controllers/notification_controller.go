@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// How often an idle event stream sends a keep-alive comment
+const eventStreamHeartbeat = 25 * time.Second
+
+type NotificationController struct{}
+
+// GetNotifications returns the current user's in-app notifications, newest first
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := config.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if notificationType := c.Query("type"); notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset((page - 1) * limit).Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	notificationService := &services.NotificationService{}
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread_count":  notificationService.UnreadCount(userID),
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	})
+}
+
+// GetUnreadCount returns how many notifications the current user hasn't read
+func (nc *NotificationController) GetUnreadCount(c *gin.Context) {
+	notificationService := &services.NotificationService{}
+	c.JSON(http.StatusOK, gin.H{"unread_count": notificationService.UnreadCount(utils.GetUserIDFromContext(c))})
+}
+
+// MarkAsRead marks one notification as read
+func (nc *NotificationController) MarkAsRead(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	notificationService := &services.NotificationService{}
+	notification, err := notificationService.MarkRead(userID, uint(notificationID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notification": notification, "unread_count": notificationService.UnreadCount(userID)})
+}
+
+// MarkAllAsRead marks all of the current user's notifications as read
+func (nc *NotificationController) MarkAllAsRead(c *gin.Context) {
+	notificationService := &services.NotificationService{}
+	updated, err := notificationService.MarkAllRead(utils.GetUserIDFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read", "updated_count": updated, "unread_count": 0})
+}
+
+// StreamEvents pushes the current user's live events (new chat messages, notifications, read
+// receipts) as server-sent events until the client disconnects
+func (nc *NotificationController) StreamEvents(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	events, unsubscribe := services.Push().Subscribe(userID)
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	// Let the client sync its badge as soon as the stream opens
+	notificationService := &services.NotificationService{}
+	c.SSEvent("ready", gin.H{"unread_count": notificationService.UnreadCount(userID)})
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Type, event.Data)
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+		}
+		return true
+	})
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationPreferenceController struct{}
+
+type NotificationPreferenceRequest struct {
+	Event   string `json:"event" binding:"required"` // An event, or "*" for all events
+	Channel string `json:"channel" binding:"required"`
+	Mode    string `json:"mode" binding:"required"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences     []NotificationPreferenceRequest `json:"preferences" binding:"dive"`
+	Timezone        *string                         `json:"timezone"`
+	QuietHoursStart *string                         `json:"quiet_hours_start"` // HH:MM, or "" to disable quiet hours
+	QuietHoursEnd   *string                         `json:"quiet_hours_end"`
+}
+
+// How unsubscribe pages describe each event's email
+var unsubscribeDescriptions = map[string]string{
+	"*":                                 "any SkillSwap notifications",
+	services.EventExchangeRequested:     "exchange request notifications",
+	services.EventExchangeStatusChanged: "exchange status updates",
+	services.EventReviewCreated:         "new review notifications",
+	services.EventMatchNew:              "new match notifications",
+	services.EventSavedSearchMatched:    "saved search alerts",
+	services.EventWeeklyDigest:          "the weekly summary",
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe - SkillSwap</title></head>
+<body style="font-family: sans-serif; max-width: 32rem; margin: 4rem auto;">
+{{if .Done}}
+<h1>You're unsubscribed</h1>
+<p>You won't receive {{.Description}} by email anymore. You can change this any time in your notification settings.</p>
+{{else}}
+<h1>Unsubscribe</h1>
+<p>Stop receiving {{.Description}} by email?</p>
+<form method="post"><button type="submit">Unsubscribe</button></form>
+{{end}}
+</body>
+</html>`))
+
+// GetPreferences returns the current user's notification preferences, timezone and quiet hours
+func (npc *NotificationPreferenceController) GetPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	preferenceService := &services.NotificationPreferenceService{}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": preferenceService.Preferences(userID),
+		"settings":    preferenceService.Settings(userID),
+	})
+}
+
+// UpdatePreferences changes some of the current user's preferences and, if given, their timezone and quiet hours
+func (npc *NotificationPreferenceController) UpdatePreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferenceService := &services.NotificationPreferenceService{}
+	for _, preference := range req.Preferences {
+		if err := preferenceService.ValidatePreference(preference.Event, preference.Channel, preference.Mode); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	settings := preferenceService.Settings(userID)
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone"})
+			return
+		}
+		settings.Timezone = *req.Timezone
+	}
+	if req.QuietHoursStart != nil {
+		settings.QuietHoursStart = *req.QuietHoursStart
+	}
+	if req.QuietHoursEnd != nil {
+		settings.QuietHoursEnd = *req.QuietHoursEnd
+	}
+	if (settings.QuietHoursStart == "") != (settings.QuietHoursEnd == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set both quiet_hours_start and quiet_hours_end, or clear both"})
+		return
+	}
+	for _, value := range []string{settings.QuietHoursStart, settings.QuietHoursEnd} {
+		if value == "" {
+			continue
+		}
+		if err := services.ValidateClock(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	for _, preference := range req.Preferences {
+		if err := preferenceService.SetPreference(userID, preference.Event, preference.Channel, preference.Mode); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification preferences"})
+			return
+		}
+	}
+	if req.Timezone != nil || req.QuietHoursStart != nil || req.QuietHoursEnd != nil {
+		settings.UserID = userID
+		if err := preferenceService.UpdateSettings(settings); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification settings"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": preferenceService.Preferences(userID),
+		"settings":    preferenceService.Settings(userID),
+	})
+}
+
+// ShowUnsubscribe asks to confirm an unsubscribe link. Opening the link changes nothing, so mail
+// scanners that follow links can't unsubscribe anyone.
+func (npc *NotificationPreferenceController) ShowUnsubscribe(c *gin.Context) {
+	_, event, err := services.ParseUnsubscribeToken(c.Query("token"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "This unsubscribe link is invalid.")
+		return
+	}
+
+	npc.renderUnsubscribePage(c, event, false)
+}
+
+// Unsubscribe turns off the email named by the token. Mail clients call it for RFC 8058 one-click
+// unsubscribe; the confirmation page's form posts here too.
+func (npc *NotificationPreferenceController) Unsubscribe(c *gin.Context) {
+	preferenceService := &services.NotificationPreferenceService{}
+	_, event, err := preferenceService.Unsubscribe(c.Query("token"))
+	if err != nil {
+		if err == services.ErrInvalidUnsubscribeToken {
+			c.String(http.StatusBadRequest, "This unsubscribe link is invalid.")
+			return
+		}
+		c.String(http.StatusInternalServerError, "Failed to unsubscribe, please try again later.")
+		return
+	}
+
+	npc.renderUnsubscribePage(c, event, true)
+}
+
+func (npc *NotificationPreferenceController) renderUnsubscribePage(c *gin.Context, event string, done bool) {
+	description, ok := unsubscribeDescriptions[event]
+	if !ok {
+		description = "these notifications"
+	}
+
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(c.Writer, gin.H{"Done": done, "Description": description})
+}
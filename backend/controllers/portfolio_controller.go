@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxPortfolioItemsPerSkill = 20
+	maxCodeSnippetLength      = 10000
+)
+
+type PortfolioController struct{}
+
+type PortfolioItemRequest struct {
+	ItemType string `json:"item_type" form:"item_type" binding:"required,oneof=file link code certificate"`
+	Title    string `json:"title" form:"title" binding:"required,max=200"`
+	Caption  string `json:"caption" form:"caption"`
+	URL      string `json:"url" form:"url"`
+	Content  string `json:"content" form:"content"`
+	Language string `json:"language" form:"language"`
+}
+
+// GetPortfolio lists a skill's portfolio items in display order
+func (pc *PortfolioController) GetPortfolio(c *gin.Context) {
+	skillID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+		return
+	}
+
+	var items []models.PortfolioItem
+	if err := config.DB.Preload("Attachment").Where("skill_id = ?", skillID).
+		Order("position ASC, id ASC").Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch portfolio"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"portfolio_items": items})
+}
+
+// AddPortfolioItem adds a link, code snippet, certificate or uploaded file to one of the current user's skills.
+// Files are sent as multipart/form-data with a "file" field; everything else as JSON.
+func (pc *PortfolioController) AddPortfolioItem(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	skill, ok := pc.loadOwnedSkill(c, userID)
+	if !ok {
+		return
+	}
+
+	limitUploadBody(c, config.AppConfig.PortfolioFileMaxBytes)
+	var req PortfolioItemRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondFormError(c, err, err.Error())
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.PortfolioItem{}).Where("skill_id = ?", skill.ID).Count(&count)
+	if count >= maxPortfolioItemsPerSkill {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A skill can have at most 20 portfolio items"})
+		return
+	}
+
+	item := models.PortfolioItem{
+		SkillID:  skill.ID,
+		UserID:   userID,
+		ItemType: req.ItemType,
+		Title:    req.Title,
+		Caption:  req.Caption,
+		Position: int(count),
+	}
+
+	// Certificates can be an upload or a link to the issuer's verification page
+	header, fileErr := c.FormFile("file")
+	hasFile := fileErr == nil
+
+	uploadService := &services.UploadService{}
+	var attachment *models.Attachment
+
+	switch {
+	case req.ItemType == "file" || (req.ItemType == "certificate" && hasFile):
+		if !hasFile {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+			return
+		}
+
+		var err error
+		attachment, err = uploadService.SavePortfolioFile(userID, header)
+		if err != nil {
+			respondUploadError(c, err)
+			return
+		}
+		item.AttachmentID = &attachment.ID
+	case req.ItemType == "link" || req.ItemType == "certificate":
+		if !isHTTPURL(req.URL) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A valid http(s) URL is required"})
+			return
+		}
+		item.URL = req.URL
+	case req.ItemType == "code":
+		if strings.TrimSpace(req.Content) == "" || len(req.Content) > maxCodeSnippetLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code snippets must be between 1 and 10000 characters"})
+			return
+		}
+		item.Content = req.Content
+		item.Language = req.Language
+	}
+
+	if err := config.DB.Create(&item).Error; err != nil {
+		if attachment != nil {
+			uploadService.DeleteAttachment(*attachment)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add portfolio item"})
+		return
+	}
+
+	config.DB.Preload("Attachment").First(&item, item.ID)
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdatePortfolioItem edits the text of a portfolio item; uploaded files can't be swapped
+func (pc *PortfolioController) UpdatePortfolioItem(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	skill, ok := pc.loadOwnedSkill(c, userID)
+	if !ok {
+		return
+	}
+
+	var item models.PortfolioItem
+	if err := config.DB.Where("id = ? AND skill_id = ?", c.Param("itemId"), skill.ID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Portfolio item not found"})
+		return
+	}
+
+	var req struct {
+		Title    string `json:"title" binding:"required,max=200"`
+		Caption  string `json:"caption"`
+		URL      string `json:"url"`
+		Content  string `json:"content"`
+		Language string `json:"language"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item.Title = req.Title
+	item.Caption = req.Caption
+
+	switch {
+	case item.AttachmentID == nil && (item.ItemType == "link" || item.ItemType == "certificate"):
+		if !isHTTPURL(req.URL) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A valid http(s) URL is required"})
+			return
+		}
+		item.URL = req.URL
+	case item.ItemType == "code":
+		if strings.TrimSpace(req.Content) == "" || len(req.Content) > maxCodeSnippetLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code snippets must be between 1 and 10000 characters"})
+			return
+		}
+		item.Content = req.Content
+		item.Language = req.Language
+	}
+
+	if err := config.DB.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update portfolio item"})
+		return
+	}
+
+	config.DB.Preload("Attachment").First(&item, item.ID)
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ReorderPortfolio sets the display order from a full list of item IDs
+func (pc *PortfolioController) ReorderPortfolio(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	skill, ok := pc.loadOwnedSkill(c, userID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ItemIDs []uint `json:"item_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existingIDs []uint
+	config.DB.Model(&models.PortfolioItem{}).Where("skill_id = ?", skill.ID).Pluck("id", &existingIDs)
+
+	// The new order must mention every item exactly once
+	existing := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+	seen := make(map[uint]bool, len(req.ItemIDs))
+	for _, id := range req.ItemIDs {
+		if !existing[id] || seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "item_ids must list each portfolio item of this skill exactly once"})
+			return
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(existing) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "item_ids must list each portfolio item of this skill exactly once"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for position, id := range req.ItemIDs {
+			if err := tx.Model(&models.PortfolioItem{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder portfolio"})
+		return
+	}
+
+	var items []models.PortfolioItem
+	config.DB.Preload("Attachment").Where("skill_id = ?", skill.ID).Order("position ASC, id ASC").Find(&items)
+
+	c.JSON(http.StatusOK, gin.H{"portfolio_items": items})
+}
+
+// DeletePortfolioItem removes a portfolio item and its uploaded file
+func (pc *PortfolioController) DeletePortfolioItem(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	skill, ok := pc.loadOwnedSkill(c, userID)
+	if !ok {
+		return
+	}
+
+	var item models.PortfolioItem
+	if err := config.DB.Preload("Attachment").Where("id = ? AND skill_id = ?", c.Param("itemId"), skill.ID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Portfolio item not found"})
+		return
+	}
+
+	if err := config.DB.Delete(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete portfolio item"})
+		return
+	}
+
+	if item.Attachment != nil {
+		uploadService := &services.UploadService{}
+		uploadService.DeleteAttachment(*item.Attachment)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Portfolio item deleted successfully"})
+}
+
+// loadOwnedSkill loads the :id skill and checks the current user owns it, writing the error response if not
+func (pc *PortfolioController) loadOwnedSkill(c *gin.Context, userID uint) (models.Skill, bool) {
+	var skill models.Skill
+
+	skillID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+		return skill, false
+	}
+
+	if err := config.DB.First(&skill, uint(skillID)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Skill not found"})
+		return skill, false
+	}
+
+	if skill.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only manage the portfolio of your own skills"})
+		return skill, false
+	}
+
+	return skill, true
+}
+
+func isHTTPURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -85,7 +85,14 @@ func (rc *ReviewController) CreateReview(c *gin.Context) {
 		Tags:       req.Tags,
 	}
 
-	if err := config.DB.Create(&review).Error; err != nil {
+	// Create the review and the reviewee's notifications together
+	notificationService := &services.NotificationService{}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return notificationService.NotifyReviewCreated(tx, review)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
 		return
 	}
@@ -93,12 +100,6 @@ func (rc *ReviewController) CreateReview(c *gin.Context) {
 	// Update user rating statistics
 	go rc.updateUserRating(revieweeID)
 
-	// Send email notification to reviewee
-	go func() {
-		emailService := &services.EmailService{}
-		emailService.SendNewReviewNotification(review)
-	}()
-
 	// Load relationships
 	config.DB.Preload("Exchange").Preload("Reviewer").Preload("Reviewee").First(&review, review.ID)
 
@@ -366,36 +367,6 @@ func (rc *ReviewController) GetPendingReviews(c *gin.Context) {
 
 // updateUserRating recalculates and updates user rating statistics
 func (rc *ReviewController) updateUserRating(userID uint) {
-	var reviews []models.Review
-	config.DB.Where("reviewee_id = ?", userID).Find(&reviews)
-
-	if len(reviews) == 0 {
-		// Delete user rating if no reviews exist
-		config.DB.Where("user_id = ?", userID).Delete(&models.UserRating{})
-		return
-	}
-
-	var totalRating int
-	var counts [6]int // index 0 unused, 1-5 for ratings
-
-	for _, review := range reviews {
-		totalRating += review.Rating
-		counts[review.Rating]++
-	}
-
-	averageRating := float64(totalRating) / float64(len(reviews))
-
-	userRating := models.UserRating{
-		UserID:        userID,
-		AverageRating: averageRating,
-		TotalReviews:  len(reviews),
-		Rating1Count:  counts[1],
-		Rating2Count:  counts[2],
-		Rating3Count:  counts[3],
-		Rating4Count:  counts[4],
-		Rating5Count:  counts[5],
-	}
-
-	// Upsert user rating
-	config.DB.Where("user_id = ?", userID).Assign(userRating).FirstOrCreate(&userRating)
+	ratingService := &services.RatingService{}
+	ratingService.RecalculateUserRating(userID)
 }
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxSavedSearchesPerUser = 20
+
+type SavedSearchController struct{}
+
+type SavedSearchRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	SkillType   string `json:"skill_type" binding:"omitempty,oneof=offering seeking"`
+	Category    string `json:"category"`
+	Level       string `json:"level" binding:"omitempty,oneof=beginner intermediate advanced expert"`
+	Search      string `json:"search"`
+	Frequency   string `json:"frequency" binding:"required,oneof=hourly daily weekly off"`
+	NotifyEmail bool   `json:"notify_email"`
+	NotifyInApp bool   `json:"notify_in_app"`
+}
+
+// GetSavedSearches lists the current user's saved searches
+func (sc *SavedSearchController) GetSavedSearches(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var searches []models.SavedSearch
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&searches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"saved_searches": searches})
+}
+
+// CreateSavedSearch saves a set of skill filters; only skills created from now on trigger alerts
+func (sc *SavedSearchController) CreateSavedSearch(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.SavedSearch{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxSavedSearchesPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can have at most 20 saved searches"})
+		return
+	}
+
+	now := time.Now()
+	search := models.SavedSearch{
+		UserID:      userID,
+		Name:        req.Name,
+		SkillType:   req.SkillType,
+		Category:    req.Category,
+		Level:       req.Level,
+		Search:      req.Search,
+		Frequency:   req.Frequency,
+		NotifyEmail: req.NotifyEmail,
+		NotifyInApp: req.NotifyInApp,
+		LastRunAt:   &now,
+	}
+
+	// Select all fields so false notification flags aren't replaced by column defaults
+	if err := config.DB.Select("*").Create(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create saved search"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// UpdateSavedSearch changes a saved search's filters or alert settings
+func (sc *SavedSearchController) UpdateSavedSearch(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	search, ok := sc.loadOwnedSearch(c, userID)
+	if !ok {
+		return
+	}
+
+	var req SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search.Name = req.Name
+	search.SkillType = req.SkillType
+	search.Category = req.Category
+	search.Level = req.Level
+	search.Search = req.Search
+	search.Frequency = req.Frequency
+	search.NotifyEmail = req.NotifyEmail
+	search.NotifyInApp = req.NotifyInApp
+
+	if err := config.DB.Save(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, search)
+}
+
+// DeleteSavedSearch removes a saved search
+func (sc *SavedSearchController) DeleteSavedSearch(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	search, ok := sc.loadOwnedSearch(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Delete(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted successfully"})
+}
+
+// GetSavedSearchResults runs a saved search now and returns the current matches
+func (sc *SavedSearchController) GetSavedSearchResults(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	search, ok := sc.loadOwnedSearch(c, userID)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	savedSearchService := &services.SavedSearchService{}
+	query := savedSearchService.Filters(search).
+		Apply(config.DB.Preload("User").Where("is_active = ? AND user_id != ?", true, userID))
+
+	var skills []models.Skill
+	var total int64
+
+	// Get total count
+	query.Model(&models.Skill{}).Count(&total)
+
+	// Get paginated results
+	if err := query.Limit(limit).Offset(offset).Order("created_at DESC").Find(&skills).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch skills"})
+		return
+	}
+
+	decorateSkills(skills, userID)
+
+	response := gin.H{
+		"saved_search": search,
+		"skills":       skills,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (sc *SavedSearchController) loadOwnedSearch(c *gin.Context, userID uint) (models.SavedSearch, bool) {
+	var search models.SavedSearch
+
+	searchID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID"})
+		return search, false
+	}
+
+	if err := config.DB.Where("id = ? AND user_id = ?", searchID, userID).First(&search).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Saved search not found"})
+		return search, false
+	}
+
+	return search, true
+}
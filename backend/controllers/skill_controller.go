@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"skillswap-backend/config"
 	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -59,30 +61,17 @@ func (sc *SkillController) CreateSkill(c *gin.Context) {
 func (sc *SkillController) GetSkills(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	skillType := c.Query("skill_type")
-	category := c.Query("category")
-	level := c.Query("level")
-	search := c.Query("search")
-
-	offset := (page - 1) * limit
-
-	query := config.DB.Preload("User").Where("is_active = ?", true)
-
-	if skillType != "" {
-		query = query.Where("skill_type = ?", skillType)
+	filters := services.SkillFilters{
+		SkillType:    c.Query("skill_type"),
+		Category:     c.Query("category"),
+		Level:        c.Query("level"),
+		Search:       c.Query("search"),
+		HasPortfolio: c.Query("has_portfolio") == "true",
 	}
 
-	if category != "" {
-		query = query.Where("category = ?", category)
-	}
+	offset := (page - 1) * limit
 
-	if level != "" {
-		query = query.Where("level = ?", level)
-	}
-
-	if search != "" {
-		query = query.Where("title ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%")
-	}
+	query := filters.Apply(config.DB.Preload("User").Where("is_active = ?", true))
 
 	var skills []models.Skill
 	var total int64
@@ -96,6 +85,8 @@ func (sc *SkillController) GetSkills(c *gin.Context) {
 		return
 	}
 
+	decorateSkills(skills, utils.GetUserIDFromContext(c))
+
 	response := gin.H{
 		"skills": skills,
 		"pagination": gin.H{
@@ -118,7 +109,10 @@ func (sc *SkillController) GetSkillByID(c *gin.Context) {
 	}
 
 	var skill models.Skill
-	if err := config.DB.Preload("User").First(&skill, uint(skillID)).Error; err != nil {
+	if err := config.DB.Preload("User").
+		Preload("PortfolioItems", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC, id ASC") }).
+		Preload("PortfolioItems.Attachment").
+		First(&skill, uint(skillID)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Skill not found"})
 			return
@@ -127,7 +121,10 @@ func (sc *SkillController) GetSkillByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, skill)
+	skills := []models.Skill{skill}
+	decorateSkills(skills, utils.GetUserIDFromContext(c))
+
+	c.JSON(http.StatusOK, skills[0])
 }
 
 func (sc *SkillController) UpdateSkill(c *gin.Context) {
@@ -228,5 +225,95 @@ func (sc *SkillController) GetUserSkills(c *gin.Context) {
 		return
 	}
 
+	decorateSkills(skills, utils.GetUserIDFromContext(c))
+
 	c.JSON(http.StatusOK, skills)
 }
+
+// EndorseSkill endorses a skill the current user learned through a completed exchange
+func (sc *SkillController) EndorseSkill(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	skillID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+		return
+	}
+
+	var req struct {
+		ExchangeID uint   `json:"exchange_id" binding:"required"`
+		Comment    string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endorsementService := &services.EndorsementService{}
+	endorsement, err := endorsementService.Endorse(userID, uint(skillID), req.ExchangeID, req.Comment)
+	if err != nil {
+		switch err {
+		case services.ErrEndorsementNotAllowed:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case services.ErrAlreadyEndorsed:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to endorse skill"})
+		}
+		return
+	}
+
+	endorsements := []models.Endorsement{*endorsement}
+	endorsementService.AttachEndorsers(endorsements)
+
+	c.JSON(http.StatusCreated, endorsements[0])
+}
+
+// GetSkillEndorsements lists all endorsements of a skill
+func (sc *SkillController) GetSkillEndorsements(c *gin.Context) {
+	skillID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid skill ID"})
+		return
+	}
+
+	var endorsements []models.Endorsement
+	if err := config.DB.Where("skill_id = ?", skillID).
+		Order("created_at DESC").Find(&endorsements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch endorsements"})
+		return
+	}
+
+	endorsementService := &services.EndorsementService{}
+	endorsementService.AttachEndorsers(endorsements)
+
+	c.JSON(http.StatusOK, gin.H{"endorsements": endorsements, "total": len(endorsements)})
+}
+
+// DeleteEndorsement withdraws an endorsement the current user gave
+func (sc *SkillController) DeleteEndorsement(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	endorsementID, err := strconv.ParseUint(c.Param("endorsementId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endorsement ID"})
+		return
+	}
+
+	var endorsement models.Endorsement
+	if err := config.DB.Where("id = ? AND skill_id = ?", endorsementID, c.Param("id")).First(&endorsement).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Endorsement not found"})
+		return
+	}
+
+	if endorsement.EndorserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only withdraw your own endorsements"})
+		return
+	}
+
+	// Hard delete so the exchange can be used to endorse again
+	if err := config.DB.Unscoped().Delete(&endorsement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete endorsement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Endorsement withdrawn successfully"})
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/services"
+	"skillswap-backend/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WebhookController manages webhook subscriptions: the current user's, or with Platform set (admin
+// routes), the platform subscriptions that receive every user's events
+type WebhookController struct {
+	Platform bool
+}
+
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required,max=2000"`
+	Events      []string `json:"events" binding:"required"` // Event names, or ["*"] for all
+	Description string   `json:"description" binding:"max=200"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// GetWebhooks lists subscriptions and the events they can subscribe to
+func (wc *WebhookController) GetWebhooks(c *gin.Context) {
+	var subscriptions []models.WebhookSubscription
+	if err := wc.scope(c).Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": subscriptions,
+		"events":   services.WebhookEvents(),
+	})
+}
+
+// CreateWebhook registers a subscription. The response holds its signing secret, which isn't shown again.
+func (wc *WebhookController) CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhookService := &services.WebhookService{}
+	subscription, ok := wc.bind(c, webhookService, models.WebhookSubscription{}, req)
+	if !ok {
+		return
+	}
+	if !wc.Platform {
+		userID := utils.GetUserIDFromContext(c)
+		subscription.UserID = &userID
+	}
+
+	if err := webhookService.Create(&subscription); err != nil {
+		if errors.Is(err, services.ErrWebhookLimit) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	// A subscription created paused stays paused
+	if req.IsActive != nil && !*req.IsActive {
+		config.DB.Model(&subscription).Update("is_active", false)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"webhook": subscription, "secret": subscription.Secret})
+}
+
+// UpdateWebhook changes a subscription's URL, events, description or whether it's active
+func (wc *WebhookController) UpdateWebhook(c *gin.Context) {
+	subscription, ok := wc.load(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhookService := &services.WebhookService{}
+	if subscription, ok = wc.bind(c, webhookService, subscription, req); !ok {
+		return
+	}
+
+	if err := config.DB.Save(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteWebhook removes a subscription and its delivery log
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	subscription, ok := wc.load(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Delete(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// RotateWebhookSecret issues a new signing secret; the old one stops working immediately
+func (wc *WebhookController) RotateWebhookSecret(c *gin.Context) {
+	subscription, ok := wc.load(c)
+	if !ok {
+		return
+	}
+
+	webhookService := &services.WebhookService{}
+	if err := webhookService.RotateSecret(&subscription); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate webhook secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": subscription.Secret})
+}
+
+// PingWebhook sends a test event now and returns the logged attempt, whether or not it succeeded
+func (wc *WebhookController) PingWebhook(c *gin.Context) {
+	subscription, ok := wc.load(c)
+	if !ok {
+		return
+	}
+
+	webhookService := &services.WebhookService{}
+	delivery, err := webhookService.Ping(subscription)
+	if delivery.ID == 0 && err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send test ping"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": delivery.Success, "delivery": delivery})
+}
+
+// GetWebhookDeliveries returns a subscription's delivery log, newest first
+func (wc *WebhookController) GetWebhookDeliveries(c *gin.Context) {
+	subscription, ok := wc.load(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := config.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscription.ID)
+
+	switch c.Query("success") {
+	case "true":
+		query = query.Where("success = ?", true)
+	case "false":
+		query = query.Where("success = ?", false)
+	}
+
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var deliveries []models.WebhookDelivery
+	var total int64
+
+	// Get total count
+	query.Count(&total)
+
+	// Get paginated results
+	if err := query.Limit(limit).Offset(offset).Order("created_at DESC").Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+		return
+	}
+
+	response := gin.H{
+		"deliveries": deliveries,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (total + int64(limit) - 1) / int64(limit),
+			"total_items":  total,
+			"per_page":     limit,
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// scope limits queries to the subscriptions this controller manages
+func (wc *WebhookController) scope(c *gin.Context) *gorm.DB {
+	if wc.Platform {
+		return config.DB.Where("user_id IS NULL")
+	}
+	return config.DB.Where("user_id = ?", utils.GetUserIDFromContext(c))
+}
+
+func (wc *WebhookController) load(c *gin.Context) (models.WebhookSubscription, bool) {
+	var subscription models.WebhookSubscription
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return subscription, false
+	}
+
+	if err := wc.scope(c).Where("id = ?", subscriptionID).First(&subscription).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return subscription, false
+	}
+
+	return subscription, true
+}
+
+// bind validates a request and applies it to a subscription
+func (wc *WebhookController) bind(c *gin.Context, webhookService *services.WebhookService, subscription models.WebhookSubscription, req WebhookRequest) (models.WebhookSubscription, bool) {
+	if err := webhookService.ValidateURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return subscription, false
+	}
+
+	events, err := webhookService.ValidateEvents(req.Events)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return subscription, false
+	}
+
+	subscription.URL = req.URL
+	subscription.Events = events
+	subscription.Description = req.Description
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+
+	return subscription, true
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"skillswap-backend/config"
+	"skillswap-backend/models"
 	"skillswap-backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -36,13 +37,38 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens outlive their accounts; deleted accounts must not keep acting
+		userID := uint(claims["user_id"].(float64))
+		var user models.User
+		if err := config.DB.Select("id").First(&user, userID).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
-		c.Set("user_id", uint(claims["user_id"].(float64)))
+		c.Set("user_id", userID)
 		c.Set("email", claims["email"].(string))
 		c.Next()
 	}
 }
 
+// AdminMiddleware restricts access to admin users. Must run after AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := utils.GetUserIDFromContext(c)
+
+		var user models.User
+		if err := config.DB.Select("id", "is_admin").First(&user, userID).Error; err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // CORSMiddleware handles CORS
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -8,13 +8,15 @@ import (
 
 type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	Email     string         `gorm:"uniqueIndex;not null" json:"email" validate:"required,email"`
+	Email     string         `gorm:"uniqueIndex;not null" json:"-" validate:"required,email"` // Only shown to the user, see Account
 	Username  string         `gorm:"uniqueIndex;not null" json:"username" validate:"required,min=3,max=20"`
 	Password  string         `gorm:"not null" json:"-" validate:"required,min=6"`
 	FullName  string         `gorm:"not null" json:"full_name" validate:"required"`
 	Bio       string         `gorm:"type:text" json:"bio"`
 	Avatar    string         `json:"avatar"`
 	Location  string         `json:"location"`
+	IsAdmin   bool           `gorm:"default:false" json:"is_admin"`
+	Locale    string         `gorm:"size:10;not null;default:'en'" json:"locale"` // Language of emails sent to the user
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -27,6 +29,18 @@ type User struct {
 	ReceivedReviews []Review    `gorm:"foreignKey:RevieweeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"received_reviews,omitempty"`
 }
 
+// Account is a user as shown to themselves. Other users see a User, which never includes the email
+// address, or a PublicProfile that includes it when the user chose to show it.
+type Account struct {
+	User
+	Email string `json:"email"`
+}
+
+// NewAccount returns the user's own view of their account
+func NewAccount(user User) Account {
+	return Account{User: user, Email: user.Email}
+}
+
 type Skill struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	UserID      uint           `gorm:"not null" json:"user_id"`
@@ -44,6 +58,14 @@ type Skill struct {
 	// Relationships
 	User      User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
 	Exchanges []Exchange `gorm:"foreignKey:SkillID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"exchanges,omitempty"`
+
+	PortfolioItems []PortfolioItem `gorm:"foreignKey:SkillID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"portfolio_items,omitempty"`
+
+	// Computed fields
+	EndorsementCount int64      `gorm:"-" json:"endorsement_count"`
+	Endorsers        []Endorser `gorm:"-" json:"endorsers,omitempty"`
+	BookmarkCount    int64      `gorm:"-" json:"bookmark_count"`
+	IsBookmarked     bool       `gorm:"-" json:"is_bookmarked"` // By the requesting user
 }
 
 type Exchange struct {
@@ -74,12 +96,15 @@ type Match struct {
 	MatchScore     int    `json:"match_score"`
 }
 
-// ChatRoom represents a chat room between two users
+// ChatRoom represents a conversation between its members: a direct chat between two users, a named
+// group, or the room for an exchange
 type ChatRoom struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
-	User1ID       uint           `gorm:"not null" json:"user1_id"`
-	User2ID       uint           `gorm:"not null" json:"user2_id"`
-	ExchangeID    *uint          `json:"exchange_id"` // Optional: link to specific exchange
+	Type          string         `gorm:"size:20;not null;default:'direct';index" json:"type" validate:"oneof=direct group exchange"`
+	Name          string         `gorm:"size:100" json:"name,omitempty"`                                                                                   // Group rooms only
+	IsPublic      bool           `gorm:"default:false" json:"is_public"`                                                                                   // Group rooms anyone can join
+	ExchangeID    *uint          `gorm:"index;uniqueIndex:idx_chat_rooms_exchange_room,where:type = 'exchange' AND deleted_at IS NULL" json:"exchange_id"` // Set for exchange rooms, one per exchange
+	DirectKey     *string        `gorm:"size:40;uniqueIndex:idx_chat_rooms_direct_key,where:deleted_at IS NULL" json:"-"`                                  // Direct rooms: "<lower user ID>:<higher user ID>", one per pair
 	IsActive      bool           `gorm:"default:true" json:"is_active"`
 	LastMessage   string         `json:"last_message,omitempty"`
 	LastMessageAt *time.Time     `json:"last_message_at,omitempty"`
@@ -88,28 +113,92 @@ type ChatRoom struct {
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
-	User1    User      `gorm:"foreignKey:User1ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user1,omitempty"`
-	User2    User      `gorm:"foreignKey:User2ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user2,omitempty"`
-	Exchange *Exchange `gorm:"foreignKey:ExchangeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"exchange,omitempty"`
-	Messages []Message `gorm:"foreignKey:ChatRoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"messages,omitempty"`
+	Exchange *Exchange        `gorm:"foreignKey:ExchangeID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"exchange,omitempty"`
+	Members  []ChatRoomMember `gorm:"foreignKey:ChatRoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"members,omitempty"`
+	Messages []Message        `gorm:"foreignKey:ChatRoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"messages,omitempty"`
+}
+
+// ChatRoomMember is a user's membership of a chat room. Owners manage group rooms.
+type ChatRoomMember struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	ChatRoomID uint       `gorm:"not null;uniqueIndex:idx_chat_room_member" json:"chat_room_id"`
+	UserID     uint       `gorm:"not null;uniqueIndex:idx_chat_room_member;index" json:"user_id"`
+	Role       string     `gorm:"size:20;not null;default:'member'" json:"role" validate:"oneof=owner member"`
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+	CreatedAt  time.Time  `json:"joined_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
 }
 
 // Message represents a chat message
 type Message struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	ChatRoomID  uint           `gorm:"not null" json:"chat_room_id"`
-	SenderID    uint           `gorm:"not null" json:"sender_id"`
-	Content     string         `gorm:"type:text;not null" json:"content" validate:"required"`
-	MessageType string         `gorm:"default:'text'" json:"message_type" validate:"oneof=text image file system"`
-	IsRead      bool           `gorm:"default:false" json:"is_read"`
-	ReadAt      *time.Time     `json:"read_at,omitempty"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	ChatRoomID   uint           `gorm:"not null" json:"chat_room_id"`
+	SenderID     uint           `gorm:"not null" json:"sender_id"`
+	Content      string         `gorm:"type:text;not null" json:"content" validate:"required"`
+	MessageType  string         `gorm:"default:'text'" json:"message_type" validate:"oneof=text image file system"`
+	AttachmentID *uint          `json:"attachment_id,omitempty"`          // Set for image and file messages
+	ParentID     *uint          `gorm:"index" json:"parent_id,omitempty"` // The message this replies to
+	IsRead       bool           `gorm:"default:false" json:"is_read"`
+	ReadAt       *time.Time     `json:"read_at,omitempty"`
+	EditedAt     *time.Time     `json:"edited_at,omitempty"`    // Last edit; earlier versions are kept as MessageEdits
+	RetractedAt  *time.Time     `json:"retracted_at,omitempty"` // Deleted for everyone; only this tombstone is left
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Computed fields
+	Reactions  []ReactionCount `gorm:"-" json:"reactions,omitempty"`
+	ReplyCount int64           `gorm:"-" json:"reply_count"`
+	Quote      *MessageQuote   `gorm:"-" json:"quote,omitempty"` // Snippet of the parent message
+
+	// Relationships
+	ChatRoom   ChatRoom    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"chat_room,omitempty"`
+	Sender     User        `gorm:"foreignKey:SenderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"sender,omitempty"`
+	Attachment *Attachment `gorm:"foreignKey:AttachmentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"attachment,omitempty"`
+	Parent     *Message    `gorm:"foreignKey:ParentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"-"`
+}
+
+// MessageQuote is the part of a parent message shown with its replies
+type MessageQuote struct {
+	MessageID  uint   `json:"message_id"`
+	SenderID   uint   `json:"sender_id"`
+	SenderName string `json:"sender_name"`
+	Snippet    string `json:"snippet"`
+	Retracted  bool   `json:"retracted"`
+}
+
+// MessageEdit is a version of a message's content that an edit replaced
+type MessageEdit struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	MessageID uint      `gorm:"not null;index" json:"message_id"`
+	Content   string    `gorm:"type:text;not null" json:"content"`
+	CreatedAt time.Time `json:"replaced_at"`
 
 	// Relationships
-	ChatRoom ChatRoom `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"chat_room,omitempty"`
-	Sender   User     `gorm:"foreignKey:SenderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"sender,omitempty"`
+	Message Message `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// MessageReaction is a user's emoji reaction to a message; a user can react with several emoji
+type MessageReaction struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	MessageID uint      `gorm:"not null;uniqueIndex:idx_message_reaction" json:"message_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_message_reaction;index" json:"user_id"`
+	Emoji     string    `gorm:"size:32;not null;uniqueIndex:idx_message_reaction" json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	Message Message `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User    User    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
+}
+
+// ReactionCount summarizes the reactions to a message with one emoji
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"` // By the requesting user
 }
 
 // Review represents a review for a completed exchange
@@ -147,3 +236,385 @@ type UserRating struct {
 	// Relationships
 	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
 }
+
+// LoginAttempt is an audit record of a login attempt
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Email     string    `gorm:"index;not null" json:"email"`
+	UserID    *uint     `gorm:"index" json:"user_id,omitempty"` // Nil when the email doesn't belong to an account
+	IPAddress string    `gorm:"index;not null" json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `gorm:"default:false" json:"success"`
+	Reason    string    `json:"reason,omitempty"` // invalid_credentials, throttled, locked
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// LoginThrottle tracks consecutive login failures for an account or an IP address
+type LoginThrottle struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Scope         string     `gorm:"not null;uniqueIndex:idx_login_throttle_scope_identifier" json:"scope"`      // account or ip
+	Identifier    string     `gorm:"not null;uniqueIndex:idx_login_throttle_scope_identifier" json:"identifier"` // Normalized email or IP address
+	FailureCount  int        `gorm:"default:0" json:"failure_count"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Attachment is an uploaded file kept in the configured storage backend
+type Attachment struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	OwnerID      uint           `gorm:"not null;index" json:"owner_id"`
+	Purpose      string         `gorm:"not null" json:"purpose" validate:"oneof=avatar chat portfolio"`
+	ChatRoomID   *uint          `gorm:"index" json:"chat_room_id,omitempty"` // Set for chat attachments
+	FileName     string         `gorm:"not null" json:"file_name"`
+	ContentType  string         `gorm:"not null" json:"content_type"`
+	Size         int64          `gorm:"not null" json:"size"`
+	StorageKey   string         `gorm:"not null" json:"-"`
+	ThumbnailKey string         `json:"-"` // Empty for non-image files
+	HasThumbnail bool           `gorm:"default:false" json:"has_thumbnail"`
+	CreatedAt    time.Time      `json:"created_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Owner    User      `gorm:"foreignKey:OwnerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	ChatRoom *ChatRoom `gorm:"foreignKey:ChatRoomID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// PrivacySettings controls what other users can see on a user's profile
+type PrivacySettings struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	UserID               uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	ShowEmail            bool      `gorm:"default:false" json:"show_email"`
+	LocationPrecision    string    `gorm:"not null;default:'city'" json:"location_precision" validate:"oneof=exact city country hidden"`
+	ShowReviews          bool      `gorm:"default:true" json:"show_reviews"`
+	ProfileVisibility    string    `gorm:"not null;default:'everyone'" json:"profile_visibility" validate:"oneof=everyone matched nobody"`
+	PublicProfileEnabled bool      `gorm:"default:false" json:"public_profile_enabled"` // Shareable link for logged-out visitors
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// PublicProfile is the curated view of a user shown to other people
+type PublicProfile struct {
+	ID                 uint          `json:"id"`
+	Username           string        `json:"username"`
+	FullName           string        `json:"full_name"`
+	Bio                string        `json:"bio"`
+	Avatar             string        `json:"avatar"`
+	Location           string        `json:"location,omitempty"`
+	Email              string        `json:"email,omitempty"`
+	MemberSince        time.Time     `json:"member_since"`
+	OfferedSkills      []Skill       `json:"offered_skills"`
+	Rating             RatingSummary `json:"rating"`
+	CompletedExchanges int64         `json:"completed_exchanges"`
+	RecentReviews      []Review      `json:"recent_reviews,omitempty"`
+}
+
+// RatingSummary is the public part of UserRating
+type RatingSummary struct {
+	AverageRating float64 `json:"average_rating"`
+	TotalReviews  int     `json:"total_reviews"`
+	Distribution  [5]int  `json:"distribution"` // Counts for 1 to 5 stars
+}
+
+// Endorsement vouches for a skill, given by someone who completed an exchange on it
+type Endorsement struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	ExchangeID uint           `gorm:"not null;uniqueIndex" json:"exchange_id"` // One endorsement per exchange
+	SkillID    uint           `gorm:"not null;index" json:"skill_id"`
+	EndorserID uint           `gorm:"not null;index" json:"endorser_id"` // The learner who requested the exchange
+	EndorseeID uint           `gorm:"not null;index" json:"endorsee_id"` // The skill owner
+	Comment    string         `gorm:"type:text" json:"comment"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Computed fields
+	EndorsedBy *Endorser `gorm:"-" json:"endorser,omitempty"` // Summary of the preloaded Endorser
+
+	// Relationships
+	Exchange Exchange `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Skill    Skill    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Endorser User     `gorm:"foreignKey:EndorserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Endorsee User     `gorm:"foreignKey:EndorseeID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Endorser is the public summary of someone who endorsed a skill
+type Endorser struct {
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	FullName   string    `json:"full_name"`
+	Avatar     string    `json:"avatar"`
+	EndorsedAt time.Time `json:"endorsed_at"`
+}
+
+// NewEndorser summarizes the endorser of an endorsement; Endorser must be preloaded
+func NewEndorser(endorsement Endorsement) Endorser {
+	return Endorser{
+		UserID:     endorsement.EndorserID,
+		Username:   endorsement.Endorser.Username,
+		FullName:   endorsement.Endorser.FullName,
+		Avatar:     endorsement.Endorser.Avatar,
+		EndorsedAt: endorsement.CreatedAt,
+	}
+}
+
+// PortfolioItem is evidence backing up a skill: an uploaded file, a link, a code snippet or a certificate
+type PortfolioItem struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	SkillID      uint           `gorm:"not null;index" json:"skill_id"`
+	UserID       uint           `gorm:"not null" json:"user_id"`
+	ItemType     string         `gorm:"not null" json:"item_type" validate:"required,oneof=file link code certificate"`
+	Title        string         `gorm:"not null" json:"title" validate:"required"`
+	Caption      string         `gorm:"type:text" json:"caption"`
+	URL          string         `json:"url,omitempty"`                      // Links and externally hosted certificates
+	Content      string         `gorm:"type:text" json:"content,omitempty"` // Code snippets
+	Language     string         `json:"language,omitempty"`                 // Code snippet language for highlighting
+	AttachmentID *uint          `json:"attachment_id,omitempty"`            // Uploaded files and certificates
+	Position     int            `gorm:"not null;default:0" json:"position"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	Attachment *Attachment `gorm:"foreignKey:AttachmentID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"attachment,omitempty"`
+}
+
+// SavedSearch is a stored set of skill filters the user wants to be alerted about
+type SavedSearch struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"`
+	Name        string         `gorm:"not null" json:"name" validate:"required"`
+	SkillType   string         `json:"skill_type"`
+	Category    string         `json:"category"`
+	Level       string         `json:"level"`
+	Search      string         `json:"search"`
+	Frequency   string         `gorm:"not null;default:'daily'" json:"frequency" validate:"oneof=hourly daily weekly off"`
+	NotifyEmail bool           `gorm:"default:true" json:"notify_email"`
+	NotifyInApp bool           `gorm:"default:true" json:"notify_in_app"`
+	LastRunAt   *time.Time     `json:"last_run_at,omitempty"` // Skills created after this are "new"
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Notification is an in-app notification shown to a user
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Type      string     `gorm:"not null" json:"type"` // e.g. saved_search.new_skills
+	Title     string     `gorm:"not null" json:"title"`
+	Body      string     `gorm:"type:text" json:"body"`
+	Link      string     `json:"link,omitempty"`                  // Frontend path to open
+	Data      string     `gorm:"type:text" json:"data,omitempty"` // JSON payload for the client
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// SkillBookmark is a skill a user saved to their shortlist
+type SkillBookmark struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_skill_bookmark_user_skill" json:"user_id"`
+	SkillID   uint      `gorm:"not null;uniqueIndex:idx_skill_bookmark_user_skill;index" json:"skill_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	User  User  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Skill Skill `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"skill,omitempty"`
+}
+
+// UserFollow records that one user follows another (e.g. a teacher they like)
+type UserFollow struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FollowerID uint      `gorm:"not null;uniqueIndex:idx_user_follow_pair" json:"follower_id"`
+	FollowedID uint      `gorm:"not null;uniqueIndex:idx_user_follow_pair;index" json:"followed_id"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relationships
+	Follower User `gorm:"foreignKey:FollowerID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Followed User `gorm:"foreignKey:FollowedID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"followed,omitempty"`
+}
+
+// MatchFeedback records how a user reacted to a suggested match (an offered skill)
+type MatchFeedback struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;uniqueIndex:idx_match_feedback_user_skill" json:"user_id"`
+	OfferedSkillID uint       `gorm:"not null;uniqueIndex:idx_match_feedback_user_skill;index" json:"offered_skill_id"`
+	Action         string     `gorm:"not null" json:"action"`  // dismiss, snooze, like
+	Category       string     `json:"category"`                // Offered skill's category, used for preference learning
+	SnoozedUntil   *time.Time `json:"snoozed_until,omitempty"` // Only for snooze
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	User         User  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	OfferedSkill Skill `gorm:"foreignKey:OfferedSkillID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"offered_skill,omitempty"`
+}
+
+// Experiment is an A/B test of matching strategies; users are bucketed deterministically into its variants
+type Experiment struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Key            string     `gorm:"uniqueIndex;not null" json:"key"`
+	Description    string     `gorm:"type:text" json:"description"`
+	Status         string     `gorm:"not null;default:'draft'" json:"status"` // draft, running, stopped
+	TrafficPercent int        `gorm:"not null;default:100" json:"traffic_percent"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	StoppedAt      *time.Time `json:"stopped_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Variants []ExperimentVariant `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"variants,omitempty"`
+}
+
+// ExperimentVariant is one arm of an experiment with its share of traffic and scoring overrides
+type ExperimentVariant struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	ExperimentID uint   `gorm:"not null;uniqueIndex:idx_experiment_variant_name" json:"experiment_id"`
+	Name         string `gorm:"not null;uniqueIndex:idx_experiment_variant_name" json:"name"`
+	Weight       int    `gorm:"not null" json:"weight"`
+	Scoring      string `gorm:"type:text" json:"scoring"` // JSON overrides of the default match scoring
+}
+
+// ExperimentExposure records which variant served a matches response
+type ExperimentExposure struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ExperimentID uint      `gorm:"not null;index" json:"experiment_id"`
+	Variant      string    `gorm:"not null" json:"variant"`
+	UserID       uint      `gorm:"not null;index" json:"user_id"`
+	Endpoint     string    `json:"endpoint"`
+	MatchCount   int       `json:"match_count"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relationships
+	Experiment Experiment `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User       User       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// ScheduledJobState tracks the last run of a scheduled job across restarts and instances
+type ScheduledJobState struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Name           string     `gorm:"uniqueIndex;not null" json:"name"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// SentNotification records a notification already delivered so scheduled jobs don't repeat it
+type SentNotification struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_sent_notification_user_kind_key" json:"user_id"`
+	Kind      string    `gorm:"not null;uniqueIndex:idx_sent_notification_user_kind_key" json:"kind"` // e.g. weekly_digest, new_match
+	Key       string    `gorm:"not null;uniqueIndex:idx_sent_notification_user_kind_key" json:"key"`  // e.g. 2026-W42, or an offered skill ID
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// NotificationPreference sets how a user receives one event on one channel. Event "*" applies to
+// every event without its own preference.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_notification_preference" json:"-"`
+	Event     string    `gorm:"not null;uniqueIndex:idx_notification_preference" json:"event"`
+	Channel   string    `gorm:"not null;uniqueIndex:idx_notification_preference" json:"channel"`
+	Mode      string    `gorm:"not null" json:"mode" validate:"oneof=immediate digest off"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// NotificationSettings holds a user's timezone and email quiet hours
+type NotificationSettings struct {
+	ID              uint      `gorm:"primaryKey" json:"-"`
+	UserID          uint      `gorm:"uniqueIndex;not null" json:"-"`
+	Timezone        string    `gorm:"not null;default:'UTC'" json:"timezone"` // IANA name, e.g. Europe/Berlin
+	QuietHoursStart string    `json:"quiet_hours_start"`                      // HH:MM local time; empty disables quiet hours
+	QuietHoursEnd   string    `json:"quiet_hours_end"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// QueuedNotification is a notification held for the user's daily digest or until their quiet hours end
+type QueuedNotification struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	Event        string     `gorm:"not null" json:"event"`
+	Channel      string     `gorm:"not null" json:"channel"`
+	Kind         string     `gorm:"not null" json:"kind"`                 // digest or deferred
+	Payload      string     `gorm:"type:text;not null" json:"-"`          // JSON NotificationMessage
+	DeliverAfter *time.Time `gorm:"index" json:"deliver_after,omitempty"` // Set for deferred notifications
+	CreatedAt    time.Time  `json:"created_at"`
+
+	// Relationships
+	User User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// OutboxMessage is a notification waiting for delivery on one channel. It is written in the same
+// transaction as the change it announces, so it is sent if and only if that change is committed.
+type OutboxMessage struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Event         string     `gorm:"not null;index" json:"event"`
+	Channel       string     `gorm:"not null" json:"channel"`
+	UserID        uint       `gorm:"index" json:"user_id"`
+	WebhookID     *uint      `gorm:"index" json:"webhook_id,omitempty"`                                             // The subscription a webhook message is for
+	Payload       string     `gorm:"type:text;not null" json:"payload"`                                             // JSON NotificationMessage
+	Status        string     `gorm:"not null;default:'pending';index:idx_outbox_status_next_attempt" json:"status"` // pending, processing, delivered or dead
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_status_next_attempt" json:"next_attempt_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"` // While processing; a crashed worker's lock expires
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"index" json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// WebhookSubscription posts events to a URL. A user's subscription receives the events addressed to
+// them; a platform subscription (no user, created by an admin) receives them for everyone.
+type WebhookSubscription struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      *uint     `gorm:"index" json:"user_id,omitempty"`
+	URL         string    `gorm:"not null" json:"url"`
+	Events      string    `gorm:"type:text;not null" json:"events"` // Comma-separated event names, or "*"
+	Description string    `json:"description"`
+	Secret      string    `gorm:"not null" json:"-"` // Signs payloads; only shown when created or rotated
+	IsActive    bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	User *User `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// WebhookDelivery logs one attempt to post an event to a webhook subscription
+type WebhookDelivery struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint      `gorm:"not null;index" json:"subscription_id"`
+	EventID        string    `gorm:"index" json:"event_id"` // Same for every attempt at one event
+	Event          string    `gorm:"not null" json:"event"`
+	RequestBody    string    `gorm:"type:text" json:"request_body"`
+	ResponseStatus int       `json:"response_status,omitempty"`
+	ResponseBody   string    `gorm:"type:text" json:"response_body,omitempty"` // First KB
+	Error          string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMS     int64     `json:"duration_ms"`
+	Success        bool      `gorm:"not null;default:false" json:"success"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+
+	// Relationships
+	Subscription WebhookSubscription `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
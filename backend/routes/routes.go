@@ -16,6 +16,16 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 	matchController := &controllers.MatchController{}
 	chatController := &controllers.ChatController{}
 	reviewController := &controllers.ReviewController{}
+	adminController := &controllers.AdminController{}
+	fileController := &controllers.FileController{}
+	portfolioController := &controllers.PortfolioController{}
+	savedSearchController := &controllers.SavedSearchController{}
+	notificationController := &controllers.NotificationController{}
+	bookmarkController := &controllers.BookmarkController{}
+	experimentController := &controllers.ExperimentController{}
+	notificationPreferenceController := &controllers.NotificationPreferenceController{}
+	webhookController := &controllers.WebhookController{}
+	platformWebhookController := &controllers.WebhookController{Platform: true}
 
 	// API group
 	api := router.Group("/api")
@@ -27,6 +37,16 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			auth.POST("/login", authController.Login)
 		}
 
+		// Shareable public profiles (opt-in)
+		api.GET("/public/users/:username", authController.GetPublicProfile)
+
+		// Email unsubscribe links (public; authorized by the signed token)
+		api.GET("/notifications/unsubscribe", notificationPreferenceController.ShowUnsubscribe)
+		api.POST("/notifications/unsubscribe", notificationPreferenceController.Unsubscribe)
+
+		// File downloads (public; chat files are protected by signed URLs)
+		api.GET("/files/:id/content", fileController.DownloadFile)
+
 		// Protected routes
 		protected := api.Group("/")
 		protected.Use(middleware.AuthMiddleware())
@@ -36,7 +56,14 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			{
 				user.GET("/profile", authController.GetProfile)
 				user.PUT("/profile", authController.UpdateProfile)
+				user.POST("/avatar", authController.UploadAvatar)
+				user.GET("/privacy", authController.GetPrivacySettings)
+				user.PUT("/privacy", authController.UpdatePrivacySettings)
+				user.GET("/export", authController.ExportData)
+				user.DELETE("", authController.DeleteAccount)
 				user.GET("/:id", authController.GetUserByID)
+				user.POST("/:id/follow", bookmarkController.FollowUser)
+				user.DELETE("/:id/follow", bookmarkController.UnfollowUser)
 			}
 
 			// Skill routes
@@ -48,6 +75,29 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 				skills.GET("/:id", skillController.GetSkillByID)
 				skills.PUT("/:id", skillController.UpdateSkill)
 				skills.DELETE("/:id", skillController.DeleteSkill)
+				skills.GET("/:id/endorsements", skillController.GetSkillEndorsements)
+				skills.POST("/:id/endorsements", skillController.EndorseSkill)
+				skills.DELETE("/:id/endorsements/:endorsementId", skillController.DeleteEndorsement)
+				skills.GET("/:id/portfolio", portfolioController.GetPortfolio)
+				skills.POST("/:id/portfolio", portfolioController.AddPortfolioItem)
+				skills.PUT("/:id/portfolio/order", portfolioController.ReorderPortfolio)
+				skills.PUT("/:id/portfolio/:itemId", portfolioController.UpdatePortfolioItem)
+				skills.DELETE("/:id/portfolio/:itemId", portfolioController.DeletePortfolioItem)
+				skills.POST("/:id/bookmark", bookmarkController.BookmarkSkill)
+				skills.DELETE("/:id/bookmark", bookmarkController.RemoveBookmark)
+			}
+
+			// Bookmark routes
+			protected.GET("/bookmarks", bookmarkController.GetBookmarks)
+
+			// Saved search routes
+			searches := protected.Group("/searches")
+			{
+				searches.GET("", savedSearchController.GetSavedSearches)
+				searches.POST("", savedSearchController.CreateSavedSearch)
+				searches.PUT("/:id", savedSearchController.UpdateSavedSearch)
+				searches.DELETE("/:id", savedSearchController.DeleteSavedSearch)
+				searches.GET("/:id/results", savedSearchController.GetSavedSearchResults)
 			}
 
 			// Exchange routes
@@ -64,6 +114,10 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			{
 				matches.GET("", matchController.GetMatches)
 				matches.GET("/advanced", matchController.GetAdvancedMatches)
+				matches.GET("/learners", matchController.GetLearners)
+				matches.GET("/feedback", matchController.GetFeedback)
+				matches.POST("/feedback", matchController.SubmitFeedback)
+				matches.DELETE("/feedback/:skillId", matchController.DeleteFeedback)
 			}
 
 			// Chat routes
@@ -71,8 +125,23 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			{
 				chat.GET("/rooms", chatController.GetChatRooms)
 				chat.POST("/rooms", chatController.CreateChatRoom)
+				chat.GET("/public-rooms", chatController.GetPublicChatRooms)
+				chat.PUT("/rooms/:roomId", chatController.UpdateChatRoom)
+				chat.POST("/rooms/:roomId/join", chatController.JoinChatRoom)
+				chat.POST("/rooms/:roomId/leave", chatController.LeaveChatRoom)
+				chat.GET("/rooms/:roomId/members", chatController.GetRoomMembers)
+				chat.POST("/rooms/:roomId/members", chatController.AddRoomMembers)
+				chat.PUT("/rooms/:roomId/members/:userId", chatController.UpdateRoomMember)
+				chat.DELETE("/rooms/:roomId/members/:userId", chatController.RemoveRoomMember)
 				chat.GET("/rooms/:roomId/messages", chatController.GetMessages)
 				chat.POST("/rooms/:roomId/messages", chatController.SendMessage)
+				chat.PUT("/rooms/:roomId/messages/:id", chatController.EditMessage)
+				chat.DELETE("/rooms/:roomId/messages/:id", chatController.DeleteMessage)
+				chat.GET("/rooms/:roomId/messages/:id/edits", chatController.GetMessageEdits)
+				chat.GET("/rooms/:roomId/messages/:id/thread", chatController.GetThread)
+				chat.POST("/rooms/:roomId/messages/:id/reactions", chatController.AddReaction)
+				chat.DELETE("/rooms/:roomId/messages/:id/reactions/:emoji", chatController.RemoveReaction)
+				chat.POST("/rooms/:roomId/attachments", chatController.UploadAttachment)
 				chat.PUT("/rooms/:roomId/read", chatController.MarkMessagesAsRead)
 				chat.DELETE("/rooms/:roomId", chatController.DeleteChatRoom)
 			}
@@ -89,6 +158,59 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 				reviews.GET("/user/:userId", reviewController.GetReviews)
 				reviews.GET("/user/:userId/rating", reviewController.GetUserRating)
 			}
+
+			// Notification routes
+			notifications := protected.Group("/notifications")
+			{
+				notifications.GET("", notificationController.GetNotifications)
+				notifications.GET("/unread-count", notificationController.GetUnreadCount)
+				notifications.GET("/preferences", notificationPreferenceController.GetPreferences)
+				notifications.PUT("/preferences", notificationPreferenceController.UpdatePreferences)
+				notifications.PUT("/read", notificationController.MarkAllAsRead)
+				notifications.PUT("/:id/read", notificationController.MarkAsRead)
+			}
+
+			// Webhook routes
+			webhooks := protected.Group("/webhooks")
+			{
+				webhooks.GET("", webhookController.GetWebhooks)
+				webhooks.POST("", webhookController.CreateWebhook)
+				webhooks.PUT("/:id", webhookController.UpdateWebhook)
+				webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+				webhooks.POST("/:id/rotate-secret", webhookController.RotateWebhookSecret)
+				webhooks.POST("/:id/ping", webhookController.PingWebhook)
+				webhooks.GET("/:id/deliveries", webhookController.GetWebhookDeliveries)
+			}
+
+			// Live events for chat and notifications (server-sent events)
+			protected.GET("/events", notificationController.StreamEvents)
+
+			// File routes
+			protected.GET("/files/:id", fileController.GetFile)
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.AdminMiddleware())
+			{
+				admin.GET("/login-attempts", adminController.GetLoginAttempts)
+				admin.GET("/experiments", experimentController.GetExperiments)
+				admin.POST("/experiments", experimentController.CreateExperiment)
+				admin.PUT("/experiments/:id/status", experimentController.UpdateExperimentStatus)
+				admin.GET("/experiments/:id/metrics", experimentController.GetExperimentMetrics)
+				admin.GET("/outbox", adminController.GetOutbox)
+				admin.POST("/outbox/replay", adminController.ReplayOutbox)
+				admin.POST("/outbox/:id/replay", adminController.ReplayOutboxMessage)
+				admin.GET("/email-templates", adminController.GetEmailTemplates)
+				admin.GET("/email-templates/:name/preview", adminController.PreviewEmailTemplate)
+				// Platform webhooks receive every user's events
+				admin.GET("/webhooks", platformWebhookController.GetWebhooks)
+				admin.POST("/webhooks", platformWebhookController.CreateWebhook)
+				admin.PUT("/webhooks/:id", platformWebhookController.UpdateWebhook)
+				admin.DELETE("/webhooks/:id", platformWebhookController.DeleteWebhook)
+				admin.POST("/webhooks/:id/rotate-secret", platformWebhookController.RotateWebhookSecret)
+				admin.POST("/webhooks/:id/ping", platformWebhookController.PingWebhook)
+				admin.GET("/webhooks/:id/deliveries", platformWebhookController.GetWebhookDeliveries)
+			}
 		}
 	}
 }
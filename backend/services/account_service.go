@@ -0,0 +1,344 @@
+package services
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const deletedContentPlaceholder = "[deleted]"
+
+// tombstoneUsername names the account that purged users' messages are reassigned to. It is longer
+// than registration allows, so nobody can sign up with it.
+const tombstoneUsername = "deleted_account_tombstone"
+
+type AccountService struct{}
+
+// UserDataExport is everything the platform stores about a user
+type UserDataExport struct {
+	ExportedAt      time.Time            `json:"exported_at"`
+	Profile         models.Account       `json:"profile"`
+	Rating          *models.UserRating   `json:"rating,omitempty"`
+	Skills          []models.Skill       `json:"skills"`
+	Exchanges       []models.Exchange    `json:"exchanges"`
+	ChatRooms       []models.ChatRoom    `json:"chat_rooms"`
+	Messages        []models.Message     `json:"messages"`
+	MessageEdits    []models.MessageEdit `json:"message_edits"`
+	GivenReviews    []models.Review      `json:"given_reviews"`
+	ReceivedReviews []models.Review      `json:"received_reviews"`
+}
+
+// BuildExport collects the user's profile, skills, exchanges, messages and reviews
+func (as *AccountService) BuildExport(userID uint) (*UserDataExport, error) {
+	export := &UserDataExport{ExportedAt: time.Now()}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	export.Profile = models.NewAccount(user)
+
+	var rating models.UserRating
+	if err := config.DB.Where("user_id = ?", userID).First(&rating).Error; err == nil {
+		export.Rating = &rating
+	}
+
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&export.Skills).Error; err != nil {
+		return nil, err
+	}
+
+	// Exchanges the user requested or received for their skills
+	if err := config.DB.Preload("Skill").
+		Where("requester_id = ? OR EXISTS (SELECT 1 FROM skills WHERE skills.id = exchanges.skill_id AND skills.user_id = ?)", userID, userID).
+		Order("created_at ASC").Find(&export.Exchanges).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Where("id IN (SELECT chat_room_id FROM chat_room_members WHERE user_id = ?)", userID).
+		Order("created_at ASC").Find(&export.ChatRooms).Error; err != nil {
+		return nil, err
+	}
+
+	// Only messages the user wrote; the other side's messages belong to them
+	if err := config.DB.Where("sender_id = ?", userID).Order("created_at ASC").Find(&export.Messages).Error; err != nil {
+		return nil, err
+	}
+	if err := config.DB.Where("message_id IN (SELECT id FROM messages WHERE sender_id = ?)", userID).
+		Order("created_at ASC").Find(&export.MessageEdits).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Where("reviewer_id = ?", userID).Order("created_at ASC").Find(&export.GivenReviews).Error; err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Where("reviewee_id = ?", userID).Order("created_at ASC").Find(&export.ReceivedReviews).Error; err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// WriteExportZip writes the export as a ZIP archive with one JSON file per section
+func (as *AccountService) WriteExportZip(w io.Writer, export *UserDataExport) error {
+	archive := zip.NewWriter(w)
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", map[string]interface{}{"profile": export.Profile, "rating": export.Rating, "exported_at": export.ExportedAt}},
+		{"skills.json", export.Skills},
+		{"exchanges.json", export.Exchanges},
+		{"chat_rooms.json", export.ChatRooms},
+		{"messages.json", export.Messages},
+		{"message_edits.json", export.MessageEdits},
+		{"reviews_given.json", export.GivenReviews},
+		{"reviews_received.json", export.ReceivedReviews},
+	}
+
+	for _, section := range sections {
+		file, err := archive.Create(section.name)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// DeleteAccount anonymizes the user's personal data and soft-deletes the account.
+// The anonymized row is kept until PurgeDeletedAccounts hard-deletes it after the retention period.
+func (as *AccountService) DeleteAccount(userID uint) error {
+	var affectedReviewees []uint
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		// Reviews the user wrote no longer count towards anyone's rating
+		if err := tx.Model(&models.Review{}).Where("reviewer_id = ?", userID).
+			Distinct().Pluck("reviewee_id", &affectedReviewees).Error; err != nil {
+			return err
+		}
+
+		// Anonymize free text in reviews written by or about the user, then retire them
+		if err := tx.Model(&models.Review{}).Where("reviewer_id = ? OR reviewee_id = ?", userID, userID).
+			Updates(map[string]interface{}{"comment": "", "tags": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("reviewer_id = ? OR reviewee_id = ?", userID, userID).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRating{}).Error; err != nil {
+			return err
+		}
+
+		// Endorsements the user gave stop counting for the skill owners
+		if err := tx.Where("endorser_id = ?", userID).Delete(&models.Endorsement{}).Error; err != nil {
+			return err
+		}
+
+		// Anonymize chat messages so counterparties keep the conversation structure
+		if err := tx.Model(&models.Message{}).Where("sender_id = ?", userID).
+			Updates(map[string]interface{}{"content": deletedContentPlaceholder, "message_type": "system"}).Error; err != nil {
+			return err
+		}
+		// Earlier versions of edited messages would undo the anonymization
+		if err := tx.Where("message_id IN (SELECT id FROM messages WHERE sender_id = ?)", userID).
+			Delete(&models.MessageEdit{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MessageReaction{}).Error; err != nil {
+			return err
+		}
+		chatService := &ChatService{}
+		if err := chatService.DeactivateUserRooms(tx, userID); err != nil {
+			return err
+		}
+
+		// Close open exchanges on both sides
+		if err := tx.Model(&models.Exchange{}).
+			Where("status IN ? AND (requester_id = ? OR skill_id IN (SELECT id FROM skills WHERE user_id = ?))",
+				[]string{"pending", "accepted"}, userID, userID).
+			Updates(map[string]interface{}{"status": "cancelled", "response_text": "Account deleted"}).Error; err != nil {
+			return err
+		}
+
+		// Cascade skills
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Skill{}).Error; err != nil {
+			return err
+		}
+
+		// Login audit records contain the original email address
+		if err := tx.Where("user_id = ? OR email = ?", userID, normalizeEmail(user.Email)).Delete(&models.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("scope = ? AND identifier = ?", ThrottleScopeAccount, normalizeEmail(user.Email)).Delete(&models.LoginThrottle{}).Error; err != nil {
+			return err
+		}
+
+		// Held-back email would never be sent; it also quotes other users' messages
+		if err := tx.Where("user_id = ?", userID).Delete(&models.QueuedNotification{}).Error; err != nil {
+			return err
+		}
+
+		// Scrub the profile; unique email/username are freed for re-registration
+		password, err := randomPasswordHash()
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":     fmt.Sprintf("deleted-%d@deleted.invalid", user.ID),
+			"username":  fmt.Sprintf("deleted_user_%d", user.ID),
+			"full_name": "Deleted User",
+			"password":  password,
+			"bio":       "",
+			"avatar":    "",
+			"location":  "",
+			"is_admin":  false,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// Avatars are personal data; remove them from storage right away
+	var avatars []models.Attachment
+	config.DB.Where("owner_id = ? AND purpose = ?", userID, "avatar").Find(&avatars)
+	uploadService := &UploadService{}
+	for _, avatar := range avatars {
+		uploadService.DeleteAttachment(avatar)
+	}
+
+	// Recompute counterparties' ratings now that the user's reviews are gone
+	ratingService := &RatingService{}
+	for _, revieweeID := range affectedReviewees {
+		ratingService.RecalculateUserRating(revieweeID)
+	}
+
+	return nil
+}
+
+// PurgeDeletedAccounts hard-deletes accounts that were deleted more than retention ago.
+// Chat messages, chat attachments and room memberships are first handed to the tombstone account so
+// counterparties keep their conversations; the remaining dependent rows go with the ON DELETE CASCADE
+// constraints.
+func (as *AccountService) PurgeDeletedAccounts(retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	var purged int64
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var userIDs []uint
+		if err := tx.Unscoped().Model(&models.User{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ? AND username <> ?", cutoff, tombstoneUsername).
+			Pluck("id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		tombstone, err := as.tombstoneUser(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Model(&models.Message{}).Where("sender_id IN ?", userIDs).
+			Update("sender_id", tombstone.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Attachment{}).Where("owner_id IN ? AND chat_room_id IS NOT NULL", userIDs).
+			Update("owner_id", tombstone.ID).Error; err != nil {
+			return err
+		}
+		// One user at a time: the tombstone can only be a member of each room once
+		for _, userID := range userIDs {
+			if err := tx.Model(&models.ChatRoomMember{}).
+				Where("user_id = ? AND chat_room_id NOT IN (SELECT chat_room_id FROM chat_room_members WHERE user_id = ?)", userID, tombstone.ID).
+				Update("user_id", tombstone.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("id IN ?", userIDs).Delete(&models.User{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+
+	return purged, err
+}
+
+// tombstoneUser returns the deleted account that stands in for purged users, creating it if needed
+func (as *AccountService) tombstoneUser(tx *gorm.DB) (models.User, error) {
+	var user models.User
+	err := tx.Unscoped().Where("username = ?", tombstoneUsername).First(&user).Error
+	if err == nil {
+		return user, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return user, err
+	}
+
+	password, err := randomPasswordHash()
+	if err != nil {
+		return user, err
+	}
+	user = models.User{
+		Email:    "tombstone@deleted.invalid",
+		Username: tombstoneUsername,
+		FullName: "Deleted User",
+		Password: password,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return user, err
+	}
+
+	// Soft-deleted so it never appears in listings and can't authenticate
+	return user, tx.Delete(&user).Error
+}
+
+// PurgeExpiredAccounts purges accounts past the configured retention period
+func (as *AccountService) PurgeExpiredAccounts() error {
+	retention := time.Duration(config.AppConfig.AccountRetentionDays) * 24 * time.Hour
+
+	purged, err := as.PurgeDeletedAccounts(retention)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Purged %d deleted accounts", purged)
+	}
+	return nil
+}
+
+// randomPasswordHash returns a hash of a random password nobody knows
+func randomPasswordHash() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return utils.HashPassword(hex.EncodeToString(buf))
+}
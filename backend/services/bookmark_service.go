@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+var ErrCannotFollowSelf = errors.New("you cannot follow yourself")
+
+type BookmarkService struct{}
+
+// BookmarkSkill adds a skill to the user's shortlist; bookmarking twice is a no-op
+func (bs *BookmarkService) BookmarkSkill(userID, skillID uint) error {
+	bookmark := models.SkillBookmark{UserID: userID, SkillID: skillID}
+	return config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&bookmark).Error
+}
+
+// RemoveBookmark removes a skill from the user's shortlist
+func (bs *BookmarkService) RemoveBookmark(userID, skillID uint) error {
+	return config.DB.Where("user_id = ? AND skill_id = ?", userID, skillID).Delete(&models.SkillBookmark{}).Error
+}
+
+// Follow makes followerID follow followedID; following twice is a no-op
+func (bs *BookmarkService) Follow(followerID, followedID uint) error {
+	if followerID == followedID {
+		return ErrCannotFollowSelf
+	}
+
+	follow := models.UserFollow{FollowerID: followerID, FollowedID: followedID}
+	return config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error
+}
+
+// Unfollow removes a follow
+func (bs *BookmarkService) Unfollow(followerID, followedID uint) error {
+	return config.DB.Where("follower_id = ? AND followed_id = ?", followerID, followedID).Delete(&models.UserFollow{}).Error
+}
+
+// AttachBookmarks fills BookmarkCount and IsBookmarked (for viewerID) on the given skills
+func (bs *BookmarkService) AttachBookmarks(skills []models.Skill, viewerID uint) {
+	if len(skills) == 0 {
+		return
+	}
+
+	skillIDs := make([]uint, len(skills))
+	for i, skill := range skills {
+		skillIDs[i] = skill.ID
+	}
+
+	var counts []struct {
+		SkillID uint
+		Count   int64
+	}
+	config.DB.Model(&models.SkillBookmark{}).
+		Select("skill_id, COUNT(*) AS count").
+		Where("skill_id IN ?", skillIDs).
+		Group("skill_id").
+		Scan(&counts)
+
+	countBySkill := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		countBySkill[row.SkillID] = row.Count
+	}
+
+	var bookmarkedIDs []uint
+	config.DB.Model(&models.SkillBookmark{}).
+		Where("user_id = ? AND skill_id IN ?", viewerID, skillIDs).
+		Pluck("skill_id", &bookmarkedIDs)
+
+	bookmarked := make(map[uint]bool, len(bookmarkedIDs))
+	for _, id := range bookmarkedIDs {
+		bookmarked[id] = true
+	}
+
+	for i := range skills {
+		skills[i].BookmarkCount = countBySkill[skills[i].ID]
+		skills[i].IsBookmarked = bookmarked[skills[i].ID]
+	}
+}
+
+// BookmarkedSkillIDs returns the set of skills userID had bookmarked before asOf
+func (bs *BookmarkService) BookmarkedSkillIDs(userID uint, asOf time.Time) map[uint]bool {
+	var skillIDs []uint
+	config.DB.Model(&models.SkillBookmark{}).Where("user_id = ? AND created_at < ?", userID, asOf).Pluck("skill_id", &skillIDs)
+
+	bookmarked := make(map[uint]bool, len(skillIDs))
+	for _, id := range skillIDs {
+		bookmarked[id] = true
+	}
+	return bookmarked
+}
+
+// FollowedUserIDs returns the set of users followerID followed before asOf
+func (bs *BookmarkService) FollowedUserIDs(followerID uint, asOf time.Time) map[uint]bool {
+	var userIDs []uint
+	config.DB.Model(&models.UserFollow{}).Where("follower_id = ? AND created_at < ?", followerID, asOf).Pluck("followed_id", &userIDs)
+
+	followed := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		followed[id] = true
+	}
+	return followed
+}
+
+// BookmarkedCategoryCounts counts the skills the user had bookmarked before asOf, per category
+func (bs *BookmarkService) BookmarkedCategoryCounts(userID uint, asOf time.Time) map[string]int {
+	var rows []struct {
+		Category string
+		Count    int
+	}
+	config.DB.Model(&models.SkillBookmark{}).
+		Select("skills.category AS category, COUNT(*) AS count").
+		Joins("JOIN skills ON skills.id = skill_bookmarks.skill_id").
+		Where("skill_bookmarks.user_id = ? AND skill_bookmarks.created_at < ?", userID, asOf).
+		Group("skills.category").
+		Scan(&rows)
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts
+}
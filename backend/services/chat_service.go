@@ -0,0 +1,595 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Chat room types
+const (
+	ChatRoomDirect   = "direct"
+	ChatRoomGroup    = "group"
+	ChatRoomExchange = "exchange"
+)
+
+// Chat room member roles
+const (
+	ChatRoleOwner  = "owner"
+	ChatRoleMember = "member"
+)
+
+const (
+	maxChatRoomMembers = 50
+	// Longest reaction, in runes; enough for emoji joined with zero-width joiners
+	maxReactionLength = 8
+	// Shown as a room's last message when it was deleted for everyone
+	retractedMessagePreview = "Message deleted"
+)
+
+var (
+	ErrChatRoomFull     = fmt.Errorf("a chat room can have at most %d members", maxChatRoomMembers)
+	ErrNotGroupRoom     = errors.New("only group rooms can change members")
+	ErrChatUserNotFound = errors.New("user not found")
+	ErrLastChatOwner    = errors.New("a group needs at least one owner")
+
+	ErrMessageNotEditable = errors.New("this message can't be changed")
+	ErrDeleteWindowPassed = errors.New("messages can only be deleted for everyone shortly after sending")
+	ErrInvalidReaction    = errors.New("reactions must be a single emoji")
+	ErrInvalidParent      = errors.New("replies must be to a message in the same room that wasn't deleted")
+)
+
+type ChatService struct{}
+
+// Membership returns a user's membership of an active room, or gorm.ErrRecordNotFound
+func (cs *ChatService) Membership(roomID, userID uint) (models.ChatRoomMember, error) {
+	var member models.ChatRoomMember
+	err := config.DB.Where("chat_room_id = ? AND user_id = ?", roomID, userID).
+		Where("EXISTS (SELECT 1 FROM chat_rooms WHERE chat_rooms.id = chat_room_id AND chat_rooms.deleted_at IS NULL)").
+		First(&member).Error
+	return member, err
+}
+
+// IsMember reports whether a user belongs to a room
+func (cs *ChatService) IsMember(roomID, userID uint) bool {
+	_, err := cs.Membership(roomID, userID)
+	return err == nil
+}
+
+// MemberIDs returns the IDs of a room's members
+func (cs *ChatService) MemberIDs(tx *gorm.DB, roomID uint) ([]uint, error) {
+	var userIDs []uint
+	err := tx.Model(&models.ChatRoomMember{}).Where("chat_room_id = ?", roomID).
+		Order("created_at, id").Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// DirectRoom returns the direct room between two users, creating it if they don't have one yet
+func (cs *ChatService) DirectRoom(userID, otherUserID uint) (models.ChatRoom, bool, error) {
+	var room models.ChatRoom
+	key := directRoomKey(userID, otherUserID)
+	err := config.DB.Where("type = ? AND direct_key = ?", ChatRoomDirect, key).First(&room).Error
+	if err == nil {
+		return room, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return room, false, err
+	}
+
+	room = models.ChatRoom{Type: ChatRoomDirect, DirectKey: &key, IsActive: true}
+	created := false
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&room)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+		_, err := cs.addMembers(tx, room, ChatRoleMember, userID, otherUserID)
+		return err
+	})
+	if err == nil && !created {
+		// A concurrent request created it first
+		room = models.ChatRoom{}
+		err = config.DB.Where("type = ? AND direct_key = ?", ChatRoomDirect, key).First(&room).Error
+	}
+	return room, created, err
+}
+
+// directRoomKey identifies the direct room of a pair of users, whichever of them starts it
+func directRoomKey(userID, otherUserID uint) string {
+	if otherUserID < userID {
+		userID, otherUserID = otherUserID, userID
+	}
+	return fmt.Sprintf("%d:%d", userID, otherUserID)
+}
+
+// CreateGroupRoom creates a named group owned by ownerID and returns it with the IDs of the other
+// users added as members
+func (cs *ChatService) CreateGroupRoom(tx *gorm.DB, ownerID uint, name string, isPublic bool, memberIDs []uint) (models.ChatRoom, []uint, error) {
+	room := models.ChatRoom{Type: ChatRoomGroup, Name: name, IsPublic: isPublic, IsActive: true}
+	if err := tx.Create(&room).Error; err != nil {
+		return room, nil, err
+	}
+	if _, err := cs.addMembers(tx, room, ChatRoleOwner, ownerID); err != nil {
+		return room, nil, err
+	}
+	added, err := cs.AddMembers(tx, room, memberIDs)
+	return room, added, err
+}
+
+// ExchangeRoom returns the room for an exchange, creating it with both parties as members if needed
+func (cs *ChatService) ExchangeRoom(tx *gorm.DB, exchange models.Exchange) (models.ChatRoom, error) {
+	var room models.ChatRoom
+	err := tx.Where("type = ? AND exchange_id = ?", ChatRoomExchange, exchange.ID).First(&room).Error
+	if err == nil {
+		return room, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return room, err
+	}
+
+	var ownerID uint
+	if err := tx.Model(&models.Skill{}).Where("id = ?", exchange.SkillID).Pluck("user_id", &ownerID).Error; err != nil {
+		return room, err
+	}
+
+	exchangeID := exchange.ID
+	room = models.ChatRoom{Type: ChatRoomExchange, ExchangeID: &exchangeID, IsActive: true}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&room)
+	if result.Error != nil {
+		return room, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// A concurrent request created it first
+		room = models.ChatRoom{}
+		err = tx.Where("type = ? AND exchange_id = ?", ChatRoomExchange, exchange.ID).First(&room).Error
+		return room, err
+	}
+	_, err = cs.addMembers(tx, room, ChatRoleMember, exchange.RequesterID, ownerID)
+	return room, err
+}
+
+// AddMembers adds users to a group room as members and returns the IDs of those who weren't in it
+// already. Unknown users fail the whole call.
+func (cs *ChatService) AddMembers(tx *gorm.DB, room models.ChatRoom, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	if room.Type != ChatRoomGroup {
+		return nil, ErrNotGroupRoom
+	}
+
+	var found int64
+	if err := tx.Model(&models.User{}).Where("id IN ?", uniqueIDs(userIDs)).Count(&found).Error; err != nil {
+		return nil, err
+	}
+	if int(found) != len(uniqueIDs(userIDs)) {
+		return nil, ErrChatUserNotFound
+	}
+
+	return cs.addMembers(tx, room, ChatRoleMember, userIDs...)
+}
+
+func (cs *ChatService) addMembers(tx *gorm.DB, room models.ChatRoom, role string, userIDs ...uint) ([]uint, error) {
+	existing, err := cs.MemberIDs(tx, room.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []uint
+	var members []models.ChatRoomMember
+	for _, userID := range uniqueIDs(userIDs) {
+		if containsID(existing, userID) {
+			continue
+		}
+		added = append(added, userID)
+		members = append(members, models.ChatRoomMember{ChatRoomID: room.ID, UserID: userID, Role: role})
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	if len(existing)+len(members) > maxChatRoomMembers {
+		return nil, ErrChatRoomFull
+	}
+
+	return added, tx.Create(&members).Error
+}
+
+// RemoveMember takes a user out of a group room. If they were its last owner, the longest-standing
+// member takes over; a room left without members is deleted.
+func (cs *ChatService) RemoveMember(tx *gorm.DB, room models.ChatRoom, userID uint) error {
+	if room.Type != ChatRoomGroup {
+		return ErrNotGroupRoom
+	}
+
+	result := tx.Where("chat_room_id = ? AND user_id = ?", room.ID, userID).Delete(&models.ChatRoomMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	var owners int64
+	if err := tx.Model(&models.ChatRoomMember{}).Where("chat_room_id = ? AND role = ?", room.ID, ChatRoleOwner).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners > 0 {
+		return nil
+	}
+
+	var successor models.ChatRoomMember
+	err := tx.Where("chat_room_id = ?", room.ID).Order("created_at, id").First(&successor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Delete(&room).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&successor).Update("role", ChatRoleOwner).Error
+}
+
+// SetRole makes a group member an owner or a plain member. The last owner can't step down.
+func (cs *ChatService) SetRole(room models.ChatRoom, member models.ChatRoomMember, role string) error {
+	if room.Type != ChatRoomGroup {
+		return ErrNotGroupRoom
+	}
+	if member.Role == ChatRoleOwner && role != ChatRoleOwner {
+		var owners int64
+		config.DB.Model(&models.ChatRoomMember{}).Where("chat_room_id = ? AND role = ?", room.ID, ChatRoleOwner).Count(&owners)
+		if owners <= 1 {
+			return ErrLastChatOwner
+		}
+	}
+	return config.DB.Model(&member).Update("role", role).Error
+}
+
+// MarkRead records that a member has read a room up to now
+func (cs *ChatService) MarkRead(member models.ChatRoomMember, at time.Time) error {
+	return config.DB.Model(&member).Update("last_read_at", at).Error
+}
+
+// AttachReadState sets whether messages count as read for the member viewing them. Direct and
+// exchange rooms keep the stored flags; in groups, where each member reads at their own pace, a
+// message is read once the member has read the room past it (senders see who read through the
+// members' last_read_at).
+func (cs *ChatService) AttachReadState(room models.ChatRoom, member models.ChatRoomMember, messages []models.Message) {
+	if room.Type != ChatRoomGroup {
+		return
+	}
+
+	for i := range messages {
+		message := &messages[i]
+		message.IsRead = false
+		message.ReadAt = nil
+		if message.SenderID == member.UserID {
+			message.IsRead = true
+		} else if member.LastReadAt != nil && !message.CreatedAt.After(*member.LastReadAt) {
+			message.IsRead = true
+			message.ReadAt = member.LastReadAt
+		}
+	}
+}
+
+// DeactivateUserRooms handles a deleted account: it leaves every group and closes its direct and
+// exchange rooms, which have no one else to talk to
+func (cs *ChatService) DeactivateUserRooms(tx *gorm.DB, userID uint) error {
+	var groups []models.ChatRoom
+	if err := tx.Where("type = ? AND id IN (SELECT chat_room_id FROM chat_room_members WHERE user_id = ?)", ChatRoomGroup, userID).
+		Find(&groups).Error; err != nil {
+		return err
+	}
+	for _, room := range groups {
+		if err := cs.RemoveMember(tx, room, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.ChatRoom{}).
+		Where("type <> ? AND id IN (SELECT chat_room_id FROM chat_room_members WHERE user_id = ?)", ChatRoomGroup, userID).
+		Update("is_active", false).Error
+}
+
+// EditMessage replaces a message's content, keeping the previous version in its edit history
+func (cs *ChatService) EditMessage(message *models.Message, content string) error {
+	if message.RetractedAt != nil || message.MessageType == "system" {
+		return ErrMessageNotEditable
+	}
+	if content == message.Content {
+		return nil
+	}
+
+	now := time.Now()
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.MessageEdit{MessageID: message.ID, Content: message.Content}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(message).Updates(map[string]interface{}{"content": content, "edited_at": now}).Error; err != nil {
+			return err
+		}
+		return cs.updateLastMessage(tx, *message, content)
+	})
+}
+
+// RetractMessage deletes a message for everyone within the delete window. The message stays as a
+// tombstone; its content, attachment, edit history, reactions and notifications go.
+func (cs *ChatService) RetractMessage(message *models.Message) error {
+	if message.RetractedAt != nil || message.MessageType == "system" {
+		return ErrMessageNotEditable
+	}
+	window := time.Duration(config.AppConfig.ChatDeleteWindowMinutes) * time.Minute
+	if time.Since(message.CreatedAt) > window {
+		return ErrDeleteWindowPassed
+	}
+
+	// Updates writes the cleared attachment_id back into message, so keep the ID to delete the file
+	attachmentID := message.AttachmentID
+
+	now := time.Now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(message).Updates(map[string]interface{}{
+			"content":       "",
+			"attachment_id": nil,
+			"retracted_at":  now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageEdit{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id = ?", message.ID).Delete(&models.MessageReaction{}).Error; err != nil {
+			return err
+		}
+		// In-app notifications quote the message. CASE keeps the cast away from other types' data.
+		if err := tx.Where("type = ? AND CASE WHEN type = ? THEN data::jsonb ->> 'message_id' = ? ELSE false END",
+			EventMessageCreated, EventMessageCreated, fmt.Sprint(message.ID)).
+			Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		return cs.updateLastMessage(tx, *message, retractedMessagePreview)
+	})
+	if err != nil {
+		return err
+	}
+
+	if attachmentID != nil {
+		var attachment models.Attachment
+		if err := config.DB.First(&attachment, *attachmentID).Error; err == nil {
+			uploadService := &UploadService{}
+			uploadService.DeleteAttachment(attachment)
+		}
+	}
+
+	message.Content = ""
+	message.AttachmentID = nil
+	message.Attachment = nil
+	message.RetractedAt = &now
+	return nil
+}
+
+// updateLastMessage changes the room's last message preview if the message is still the latest
+func (cs *ChatService) updateLastMessage(tx *gorm.DB, message models.Message, preview string) error {
+	return tx.Model(&models.ChatRoom{}).
+		Where("id = ? AND NOT EXISTS (SELECT 1 FROM messages WHERE chat_room_id = ? AND id > ? AND deleted_at IS NULL)",
+			message.ChatRoomID, message.ChatRoomID, message.ID).
+		Update("last_message", preview).Error
+}
+
+// AddReaction records a user's emoji reaction to a message; reacting twice with the same emoji is a no-op
+func (cs *ChatService) AddReaction(message models.Message, userID uint, emoji string) error {
+	if message.RetractedAt != nil {
+		return ErrMessageNotEditable
+	}
+	if !isReaction(emoji) {
+		return ErrInvalidReaction
+	}
+
+	reaction := models.MessageReaction{MessageID: message.ID, UserID: userID, Emoji: emoji}
+	return config.DB.Where(reaction).FirstOrCreate(&reaction).Error
+}
+
+// RemoveReaction takes back a user's reaction, returning gorm.ErrRecordNotFound if there wasn't one
+func (cs *ChatService) RemoveReaction(message models.Message, userID uint, emoji string) error {
+	result := config.DB.Where("message_id = ? AND user_id = ? AND emoji = ?", message.ID, userID, emoji).
+		Delete(&models.MessageReaction{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AttachReactions fills Reactions on the given messages, with Reacted for viewerID
+func (cs *ChatService) AttachReactions(messages []models.Message, viewerID uint) {
+	if len(messages) == 0 {
+		return
+	}
+
+	messageIDs := make([]uint, len(messages))
+	for i, message := range messages {
+		messageIDs[i] = message.ID
+	}
+
+	var rows []struct {
+		MessageID uint
+		Emoji     string
+		Count     int64
+		Reacted   bool
+	}
+	config.DB.Model(&models.MessageReaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, BOOL_OR(user_id = ?) AS reacted", viewerID).
+		Where("message_id IN ?", messageIDs).
+		Group("message_id, emoji").
+		Order("MIN(created_at)").
+		Scan(&rows)
+
+	reactions := make(map[uint][]models.ReactionCount)
+	for _, row := range rows {
+		reactions[row.MessageID] = append(reactions[row.MessageID], models.ReactionCount{
+			Emoji:   row.Emoji,
+			Count:   row.Count,
+			Reacted: row.Reacted,
+		})
+	}
+
+	for i := range messages {
+		messages[i].Reactions = reactions[messages[i].ID]
+	}
+}
+
+// ValidateParent checks that a reply's parent is a message in the room that wasn't deleted
+func (cs *ChatService) ValidateParent(roomID, parentID uint) error {
+	var parent models.Message
+	if err := config.DB.Where("id = ? AND chat_room_id = ?", parentID, roomID).First(&parent).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidParent
+		}
+		return err
+	}
+	if parent.RetractedAt != nil {
+		return ErrInvalidParent
+	}
+	return nil
+}
+
+// AttachThreads fills ReplyCount on the given messages, and Quote on those that are replies
+func (cs *ChatService) AttachThreads(messages []models.Message) {
+	if len(messages) == 0 {
+		return
+	}
+
+	messageIDs := make([]uint, len(messages))
+	var parentIDs []uint
+	for i, message := range messages {
+		messageIDs[i] = message.ID
+		if message.ParentID != nil {
+			parentIDs = append(parentIDs, *message.ParentID)
+		}
+	}
+
+	var counts []struct {
+		ParentID uint
+		Count    int64
+	}
+	config.DB.Model(&models.Message{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ? AND retracted_at IS NULL", messageIDs).
+		Group("parent_id").
+		Scan(&counts)
+
+	replyCounts := make(map[uint]int64)
+	for _, row := range counts {
+		replyCounts[row.ParentID] = row.Count
+	}
+
+	quotes := make(map[uint]*models.MessageQuote)
+	if len(parentIDs) > 0 {
+		var parents []models.Message
+		config.DB.Preload("Sender").Where("id IN ?", uniqueIDs(parentIDs)).Find(&parents)
+		for _, parent := range parents {
+			quote := &models.MessageQuote{
+				MessageID:  parent.ID,
+				SenderID:   parent.SenderID,
+				SenderName: parent.Sender.FullName,
+				Retracted:  parent.RetractedAt != nil,
+			}
+			if !quote.Retracted {
+				quote.Snippet = messagePreview(parent)
+			}
+			quotes[parent.ID] = quote
+		}
+	}
+
+	for i := range messages {
+		messages[i].ReplyCount = replyCounts[messages[i].ID]
+		if messages[i].ParentID != nil {
+			messages[i].Quote = quotes[*messages[i].ParentID]
+		}
+	}
+}
+
+// isReaction accepts a short run of non-ASCII, printable characters: an emoji, possibly with
+// skin tone modifiers or zero-width joiners, but not words
+func isReaction(emoji string) bool {
+	runes := []rune(emoji)
+	if len(runes) == 0 || len(runes) > maxReactionLength {
+		return false
+	}
+	for _, r := range runes {
+		if r < utf8.RuneSelf || unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// MigrateChatRooms moves rooms from the old user1_id/user2_id columns to memberships, then drops the
+// columns, and keys direct rooms that predate direct_key. It runs at startup. Old rooms were one per
+// pair of users, so they stay direct rooms even if they mention an exchange.
+func MigrateChatRooms() error {
+	if config.DB.Migrator().HasColumn(&models.ChatRoom{}, "user1_id") {
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				INSERT INTO chat_room_members (chat_room_id, user_id, role, created_at, updated_at)
+				SELECT id, user1_id, ?, created_at, created_at FROM chat_rooms
+				UNION
+				SELECT id, user2_id, ?, created_at, created_at FROM chat_rooms
+				ON CONFLICT DO NOTHING`, ChatRoleMember, ChatRoleMember).Error; err != nil {
+				return err
+			}
+			for _, column := range []string{"user1_id", "user2_id"} {
+				if err := tx.Migrator().DropColumn(&models.ChatRoom{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Where a pair ended up with several direct rooms, the oldest one gets the key
+	return config.DB.Exec(`
+		UPDATE chat_rooms SET direct_key = pairs.direct_key
+		FROM (
+			SELECT chat_room_id, MIN(user_id) || ':' || MAX(user_id) AS direct_key,
+				ROW_NUMBER() OVER (PARTITION BY MIN(user_id), MAX(user_id) ORDER BY chat_room_id) AS position
+			FROM chat_room_members
+			JOIN chat_rooms ON chat_rooms.id = chat_room_members.chat_room_id
+			WHERE chat_rooms.type = ? AND chat_rooms.deleted_at IS NULL
+			GROUP BY chat_room_id
+			HAVING COUNT(*) = 2
+		) pairs
+		WHERE chat_rooms.id = pairs.chat_room_id AND pairs.position = 1 AND chat_rooms.direct_key IS NULL
+			AND NOT EXISTS (SELECT 1 FROM chat_rooms keyed WHERE keyed.direct_key = pairs.direct_key AND keyed.deleted_at IS NULL)`,
+		ChatRoomDirect).Error
+}
+
+func uniqueIDs(ids []uint) []uint {
+	var unique []uint
+	for _, id := range ids {
+		if !containsID(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"skillswap-backend/models"
+	"time"
+)
+
+var ErrUnknownEmailTemplate = errors.New("unknown email template")
+
+// PreviewEmail renders a template in a locale with made-up data, so admins can check layout and
+// translations without triggering a real notification
+func PreviewEmail(name, locale string) (EmailTemplate, error) {
+	data, ok := sampleEmailData(ResolveLocale(locale))[name]
+	if !ok {
+		return EmailTemplate{}, ErrUnknownEmailTemplate
+	}
+	return RenderEmail(name, locale, data)
+}
+
+// sampleEmailData returns preview data for every template, built by the same functions as real email
+func sampleEmailData(locale string) map[string]map[string]interface{} {
+	recipient := models.User{ID: 1, FullName: "Alex Rivera", Email: "alex@example.com", Locale: locale}
+	other := models.User{ID: 2, FullName: "Sam Chen", Email: "sam@example.com", Locale: DefaultLocale}
+
+	skill := models.Skill{ID: 10, UserID: recipient.ID, Title: "Conversational Spanish", Category: "Languages", Level: "intermediate", User: recipient}
+	exchange := models.Exchange{
+		ID:           20,
+		RequesterID:  other.ID,
+		SkillID:      skill.ID,
+		Status:       "accepted",
+		Message:      "I'd love to practise before a trip to Madrid <3 Could we meet weekly?",
+		ResponseText: "Sounds great, let's start next Monday!",
+	}
+
+	matches := []models.Match{
+		{UserID: 2, UserName: "Sam Chen", OfferedSkillID: 11, OfferedSkill: "Guitar", SeekingSkill: "Conversational Spanish", MatchScore: 185},
+		{UserID: 3, UserName: "Priya Patel", OfferedSkillID: 12, OfferedSkill: "Python", SeekingSkill: "Conversational Spanish", MatchScore: 160},
+		{UserID: 4, UserName: "Jonas Weber", OfferedSkillID: 13, OfferedSkill: "Watercolor Painting", SeekingSkill: "Conversational Spanish", MatchScore: 130},
+	}
+
+	review := models.Review{ID: 30, ExchangeID: exchange.ID, ReviewerID: other.ID, RevieweeID: recipient.ID, Rating: 4, Comment: "Patient teacher & great conversation topics."}
+
+	search := models.SavedSearch{ID: 40, UserID: recipient.ID, Name: "Guitar for beginners"}
+	var skills []models.Skill
+	for i := 1; i <= maxSkillsPerAlert+2; i++ {
+		skills = append(skills, models.Skill{
+			ID:       uint(100 + i),
+			Title:    fmt.Sprintf("Beginner guitar lesson #%d", i),
+			Category: "Music",
+			Level:    "beginner",
+			User:     other,
+		})
+	}
+
+	return map[string]map[string]interface{}{
+		EmailExchangeRequest:  exchangeRequestEmailData(recipient, other, skill, exchange),
+		EmailExchangeStatus:   exchangeStatusEmailData(recipient, other, skill, exchange),
+		EmailNewMatch:         newMatchEmailData(recipient, matches),
+		EmailNewReview:        newReviewEmailData(recipient, other, review),
+		EmailAccountLocked:    accountLockedEmailData(recipient, "203.0.113.7", time.Now().Add(15*time.Minute).Truncate(time.Minute)),
+		EmailSavedSearchAlert: savedSearchAlertEmailData(recipient, search, skills),
+		EmailWeeklyDigest:     weeklyDigestEmailData(recipient, WeeklyStats{NewExchanges: 3, NewMessages: 12, CompletedSkills: 1, NewReviews: 2}),
+		EmailDailyDigest: dailyDigestEmailData(recipient, []DigestItem{
+			{Subject: "New Skill Exchange Request - Conversational Spanish", Summary: "Sam Chen requested an exchange for Conversational Spanish", Link: "/exchanges/20"},
+			{Subject: "You received a new review!", Summary: "Sam Chen left you a 4-star review", Link: "/profile"},
+		}),
+	}
+}
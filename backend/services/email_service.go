@@ -2,269 +2,162 @@ package services
 
 import (
 	"fmt"
-	"log"
-	"net/smtp"
-	"os"
 	"skillswap-backend/config"
 	"skillswap-backend/models"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type EmailService struct{}
 
+// EmailTemplate is a rendered email
 type EmailTemplate struct {
-	Subject string
-	Body    string
+	Subject  string
+	Body     string // Plain text
+	HTMLBody string
 }
 
-// SendEmailNotification sends email notifications for various events
-func (es *EmailService) SendEmailNotification(to, subject, body string) error {
-	// Email configuration - should be in environment variables
-	smtpHost := os.Getenv("SMTP_HOST")
-	smtpPort := os.Getenv("SMTP_PORT")
-	smtpUser := os.Getenv("SMTP_USER")
-	smtpPass := os.Getenv("SMTP_PASS")
-
-	if smtpHost == "" {
-		smtpHost = "smtp.gmail.com"
-	}
-	if smtpPort == "" {
-		smtpPort = "587"
-	}
-
-	// Skip sending if credentials not configured
-	if smtpUser == "" || smtpPass == "" {
-		log.Printf("Email not sent to %s: SMTP credentials not configured", to)
-		return nil
+// SendWeeklyDigestNotification sends weekly summary of activity, through the outbox as part of tx
+func (es *EmailService) SendWeeklyDigestNotification(tx *gorm.DB, userID uint) error {
+	var user models.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return err
 	}
 
-	from := smtpUser
-
-	// Message
-	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s", from, to, subject, body)
-
-	// Authentication
-	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
-
-	// Send email
-	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{to}, []byte(message))
+	// Gather weekly stats
+	weeklyStats := es.getWeeklyStats(userID)
+	template, err := RenderEmail(EmailWeeklyDigest, user.Locale, weeklyDigestEmailData(user, weeklyStats))
 	if err != nil {
-		log.Printf("Failed to send email to %s: %v", to, err)
 		return err
 	}
 
-	log.Printf("Email sent successfully to %s", to)
-	return nil
+	return Notifications().PublishTo(tx, NotificationMessage{
+		Event:    EventWeeklyDigest,
+		UserID:   user.ID,
+		Email:    user.Email,
+		Locale:   user.Locale,
+		Subject:  template.Subject,
+		Body:     template.Body,
+		HTMLBody: template.HTMLBody,
+		Summary:  "Your weekly activity summary",
+		Link:     "/dashboard",
+	}, ChannelEmail)
 }
 
-// SendExchangeRequestNotification sends notification when someone requests a skill exchange
-func (es *EmailService) SendExchangeRequestNotification(exchange models.Exchange) error {
-	// Get skill owner's email
-	var skillOwner models.User
-	if err := config.DB.Joins("JOIN skills ON users.id = skills.user_id").
-		Where("skills.id = ?", exchange.SkillID).First(&skillOwner).Error; err != nil {
-		return err
+// SendSavedSearchAlertNotification sends new skills matching one of the user's saved searches, through
+// the outbox as part of tx
+func (es *EmailService) SendSavedSearchAlertNotification(tx *gorm.DB, search models.SavedSearch, skills []models.Skill) error {
+	if len(skills) == 0 {
+		return nil
 	}
 
-	// Get requester info
-	var requester models.User
-	if err := config.DB.First(&requester, exchange.RequesterID).Error; err != nil {
+	var user models.User
+	if err := tx.First(&user, search.UserID).Error; err != nil {
 		return err
 	}
 
-	// Get skill info
-	var skill models.Skill
-	if err := config.DB.First(&skill, exchange.SkillID).Error; err != nil {
+	template, err := RenderEmail(EmailSavedSearchAlert, user.Locale, savedSearchAlertEmailData(user, search, skills))
+	if err != nil {
 		return err
 	}
 
-	template := es.getExchangeRequestTemplate(requester, skill, exchange)
-	return es.SendEmailNotification(skillOwner.Email, template.Subject, template.Body)
+	return Notifications().PublishTo(tx, NotificationMessage{
+		Event:    EventSavedSearchMatched,
+		UserID:   user.ID,
+		Email:    user.Email,
+		Locale:   user.Locale,
+		Subject:  template.Subject,
+		Body:     template.Body,
+		HTMLBody: template.HTMLBody,
+		Summary:  fmt.Sprintf("%d new skills match \"%s\"", len(skills), search.Name),
+		Link:     fmt.Sprintf("/skills?saved_search=%d", search.ID),
+	}, ChannelEmail)
 }
 
-// SendExchangeStatusUpdateNotification sends notification when exchange status changes
-func (es *EmailService) SendExchangeStatusUpdateNotification(exchange models.Exchange) error {
-	// Get requester's email
-	var requester models.User
-	if err := config.DB.First(&requester, exchange.RequesterID).Error; err != nil {
-		return err
-	}
+// Template data. Each function returns the values one of the templates in templates/email expects,
+// for the recipient given first.
 
-	// Get skill and skill owner info
-	var skill models.Skill
-	if err := config.DB.Preload("User").First(&skill, exchange.SkillID).Error; err != nil {
-		return err
+func exchangeRequestEmailData(recipient, requester models.User, skill models.Skill, exchange models.Exchange) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Requester": requester,
+		"Skill":     skill,
+		"Exchange":  exchange,
 	}
-
-	template := es.getExchangeStatusUpdateTemplate(skill.User, skill, exchange)
-	return es.SendEmailNotification(requester.Email, template.Subject, template.Body)
 }
 
-// SendNewMatchNotification sends notification when new matches are found
-func (es *EmailService) SendNewMatchNotification(userID uint, matches []models.Match) error {
-	if len(matches) == 0 {
-		return nil
+func exchangeStatusEmailData(recipient, skillOwner models.User, skill models.Skill, exchange models.Exchange) map[string]interface{} {
+	// Statuses without their own wording share the generic "other" one
+	outcome := exchange.Status
+	switch outcome {
+	case "accepted", "rejected", "completed", "cancelled":
+	default:
+		outcome = "other"
 	}
 
-	var user models.User
-	if err := config.DB.First(&user, userID).Error; err != nil {
-		return err
+	return map[string]interface{}{
+		"Recipient":  recipient,
+		"SkillOwner": skillOwner,
+		"Skill":      skill,
+		"Exchange":   exchange,
+		"Outcome":    outcome,
 	}
-
-	template := es.getNewMatchTemplate(user, matches)
-	return es.SendEmailNotification(user.Email, template.Subject, template.Body)
 }
 
-// SendNewReviewNotification sends notification when someone leaves a review
-func (es *EmailService) SendNewReviewNotification(review models.Review) error {
-	// Get reviewee's email
-	var reviewee models.User
-	if err := config.DB.First(&reviewee, review.RevieweeID).Error; err != nil {
-		return err
+func newMatchEmailData(recipient models.User, matches []models.Match) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Matches":   matches[:min(len(matches), 5)], // Show max 5 matches
 	}
-
-	// Get reviewer info
-	var reviewer models.User
-	if err := config.DB.First(&reviewer, review.ReviewerID).Error; err != nil {
-		return err
-	}
-
-	template := es.getNewReviewTemplate(reviewer, reviewee, review)
-	return es.SendEmailNotification(reviewee.Email, template.Subject, template.Body)
 }
 
-// SendWeeklyDigestNotification sends weekly summary of activity
-func (es *EmailService) SendWeeklyDigestNotification(userID uint) error {
-	var user models.User
-	if err := config.DB.First(&user, userID).Error; err != nil {
-		return err
+func newReviewEmailData(recipient, reviewer models.User, review models.Review) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Reviewer":  reviewer,
+		"Review":    review,
+		"Stars":     strings.Repeat("⭐", review.Rating) + strings.Repeat("☆", 5-review.Rating),
 	}
-
-	// Gather weekly stats
-	weeklyStats := es.getWeeklyStats(userID)
-	template := es.getWeeklyDigestTemplate(user, weeklyStats)
-
-	return es.SendEmailNotification(user.Email, template.Subject, template.Body)
 }
 
-// Template functions
-func (es *EmailService) getExchangeRequestTemplate(requester models.User, skill models.Skill, exchange models.Exchange) EmailTemplate {
-	subject := fmt.Sprintf("New Skill Exchange Request - %s", skill.Title)
-
-	body := fmt.Sprintf(`
-Hello!
-
-You have received a new skill exchange request on SkillSwap.
-
-Request Details:
-- From: %s (%s)
-- Skill: %s
-- Category: %s
-- Level: %s
-- Message: "%s"
-
-To respond to this request, please log in to your SkillSwap account:
-https://your-skillswap-app.com/exchanges
-
-Best regards,
-The SkillSwap Team
-`, requester.FullName, requester.Email, skill.Title, skill.Category, skill.Level, exchange.Message)
-
-	return EmailTemplate{Subject: subject, Body: body}
+func accountLockedEmailData(recipient models.User, ipAddress string, lockedUntil time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient":   recipient,
+		"IPAddress":   ipAddress,
+		"LockedUntil": lockedUntil,
+	}
 }
 
-func (es *EmailService) getExchangeStatusUpdateTemplate(skillOwner models.User, skill models.Skill, exchange models.Exchange) EmailTemplate {
-	var subject, statusText string
-
-	switch exchange.Status {
-	case "accepted":
-		subject = "Exchange Request Accepted!"
-		statusText = "accepted"
-	case "rejected":
-		subject = "Exchange Request Update"
-		statusText = "declined"
-	case "completed":
-		subject = "Exchange Completed!"
-		statusText = "marked as completed"
-	case "cancelled":
-		subject = "Exchange Cancelled"
-		statusText = "cancelled"
-	default:
-		subject = "Exchange Status Update"
-		statusText = "updated"
+func newMessageEmailData(recipient, sender models.User, room models.ChatRoom, preview string) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Sender":    sender,
+		"Room":      room,
+		"Group":     room.Type == ChatRoomGroup,
+		"Preview":   preview,
+		"Link":      fmt.Sprintf("/chat/%d", room.ID),
 	}
-
-	body := fmt.Sprintf(`
-Hello!
-
-Your skill exchange request has been %s.
-
-Exchange Details:
-- Skill Owner: %s
-- Skill: %s
-- Status: %s
-- Response: "%s"
-
-To view your exchanges, please log in to your SkillSwap account:
-https://your-skillswap-app.com/exchanges
-
-Best regards,
-The SkillSwap Team
-`, statusText, skillOwner.FullName, skill.Title, strings.ToUpper(string(exchange.Status[0]))+exchange.Status[1:], exchange.ResponseText)
-
-	return EmailTemplate{Subject: subject, Body: body}
 }
 
-func (es *EmailService) getNewMatchTemplate(user models.User, matches []models.Match) EmailTemplate {
-	subject := fmt.Sprintf("New Skill Matches Found - %d potential matches!", len(matches))
-
-	matchList := ""
-	for i, match := range matches[:min(len(matches), 5)] { // Show max 5 matches
-		matchList += fmt.Sprintf("%d. %s offers %s (Match Score: %d%%)\n",
-			i+1, match.UserName, match.OfferedSkill, match.MatchScore)
+func chatInvitedEmailData(recipient, inviter models.User, room models.ChatRoom) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Inviter":   inviter,
+		"Room":      room,
+		"Link":      fmt.Sprintf("/chat/%d", room.ID),
 	}
-
-	body := fmt.Sprintf(`
-Hello %s!
-
-We found new skill matches for you on SkillSwap!
-
-Top Matches:
-%s
-
-To view all matches and connect with these users, please log in to your account:
-https://your-skillswap-app.com/matches
-
-Happy skill swapping!
-The SkillSwap Team
-`, user.FullName, matchList)
-
-	return EmailTemplate{Subject: subject, Body: body}
 }
 
-func (es *EmailService) getNewReviewTemplate(reviewer, reviewee models.User, review models.Review) EmailTemplate {
-	subject := "You received a new review!"
-
-	stars := strings.Repeat("⭐", review.Rating) + strings.Repeat("☆", 5-review.Rating)
-
-	body := fmt.Sprintf(`
-Hello %s!
-
-You have received a new review on SkillSwap!
-
-Review Details:
-- From: %s
-- Rating: %s (%d/5 stars)
-- Comment: "%s"
-
-To view your complete review history, please log in to your account:
-https://your-skillswap-app.com/profile
-
-Thank you for being an active member of the SkillSwap community!
-The SkillSwap Team
-`, reviewee.FullName, reviewer.FullName, stars, review.Rating, review.Comment)
-
-	return EmailTemplate{Subject: subject, Body: body}
+func savedSearchAlertEmailData(recipient models.User, search models.SavedSearch, skills []models.Skill) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Search":    search,
+		"Skills":    skills[:min(len(skills), maxSkillsPerAlert)],
+		"More":      max(len(skills)-maxSkillsPerAlert, 0),
+		"Link":      fmt.Sprintf("/skills?saved_search=%d", search.ID),
+	}
 }
 
 type WeeklyStats struct {
@@ -277,56 +170,49 @@ type WeeklyStats struct {
 
 func (es *EmailService) getWeeklyStats(userID uint) WeeklyStats {
 	var stats WeeklyStats
+	weekAgo := time.Now().AddDate(0, 0, -7)
 
 	// Count exchanges from last week
 	config.DB.Model(&models.Exchange{}).
-		Where("requester_id = ? AND created_at > NOW() - INTERVAL 7 DAY", userID).
+		Where("requester_id = ? AND created_at > ?", userID, weekAgo).
 		Count(&stats.NewExchanges)
 
 	// Count messages from last week
 	config.DB.Model(&models.Message{}).
-		Where("sender_id = ? AND created_at > NOW() - INTERVAL 7 DAY", userID).
+		Where("sender_id = ? AND created_at > ?", userID, weekAgo).
 		Count(&stats.NewMessages)
 
 	// Count completed exchanges from last week
 	config.DB.Model(&models.Exchange{}).
 		Joins("JOIN skills ON exchanges.skill_id = skills.id").
-		Where("(exchanges.requester_id = ? OR skills.user_id = ?) AND exchanges.status = 'completed' AND exchanges.updated_at > NOW() - INTERVAL 7 DAY", userID, userID).
+		Where("(exchanges.requester_id = ? OR skills.user_id = ?) AND exchanges.status = 'completed' AND exchanges.updated_at > ?", userID, userID, weekAgo).
 		Count(&stats.CompletedSkills)
 
 	// Count new reviews from last week
 	config.DB.Model(&models.Review{}).
-		Where("reviewee_id = ? AND created_at > NOW() - INTERVAL 7 DAY", userID).
+		Where("reviewee_id = ? AND created_at > ?", userID, weekAgo).
 		Count(&stats.NewReviews)
 
 	return stats
 }
 
-func (es *EmailService) getWeeklyDigestTemplate(user models.User, stats WeeklyStats) EmailTemplate {
-	subject := "Your Weekly SkillSwap Summary"
-
-	body := fmt.Sprintf(`
-Hello %s!
-
-Here's your weekly SkillSwap activity summary:
-
-📈 This Week's Activity:
-- New Exchange Requests: %d
-- Messages Sent: %d
-- Skills Completed: %d
-- New Reviews Received: %d
-
-🎯 Keep Growing:
-- Log in to check for new skill matches
-- Complete pending exchanges
-- Update your skill offerings
-
-Ready to continue your skill journey?
-https://your-skillswap-app.com/dashboard
+func weeklyDigestEmailData(recipient models.User, stats WeeklyStats) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Stats":     stats,
+	}
+}
 
-Happy learning!
-The SkillSwap Team
-`, user.FullName, stats.NewExchanges, stats.NewMessages, stats.CompletedSkills, stats.NewReviews)
+// DigestItem is one notification listed in a daily digest
+type DigestItem struct {
+	Subject string
+	Summary string
+	Link    string
+}
 
-	return EmailTemplate{Subject: subject, Body: body}
+func dailyDigestEmailData(recipient models.User, items []DigestItem) map[string]interface{} {
+	return map[string]interface{}{
+		"Recipient": recipient,
+		"Items":     items,
+	}
 }
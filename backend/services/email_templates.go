@@ -0,0 +1,278 @@
+package services
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"skillswap-backend/config"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email templates live in templates/email as <name>.txt, which also defines the "subject", and
+// <name>.html; both fill in the "content" block of the matching layout. Strings come from the
+// translation catalogs in templates/locales, one JSON file per locale.
+//
+//go:embed templates/email/*.txt templates/email/*.html templates/locales/*.json
+var emailTemplateFiles embed.FS
+
+// DefaultLocale is used for users without a supported locale, and for keys a catalog doesn't translate
+const DefaultLocale = "en"
+
+// Email template names
+const (
+	EmailExchangeRequest  = "exchange_request"
+	EmailExchangeStatus   = "exchange_status"
+	EmailNewMatch         = "new_match"
+	EmailNewReview        = "new_review"
+	EmailAccountLocked    = "account_locked"
+	EmailNewMessage       = "new_message"
+	EmailChatInvited      = "chat_invited"
+	EmailSavedSearchAlert = "saved_search_alert"
+	EmailWeeklyDigest     = "weekly_digest"
+	EmailDailyDigest      = "daily_digest"
+)
+
+// emailTemplate is one email parsed for one locale
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+type emailTemplateSet struct {
+	names     []string
+	catalogs  map[string]map[string]string
+	templates map[string]map[string]emailTemplate // By locale, then name
+}
+
+// emailButton and emailField are passed to the "button" and "field" blocks of the HTML layout
+type emailButton struct {
+	URL   string
+	Label string
+}
+
+type emailField struct {
+	Label string
+	Value interface{}
+}
+
+var (
+	emailTemplates     *emailTemplateSet
+	emailTemplatesErr  error
+	emailTemplatesOnce sync.Once
+)
+
+// LoadEmailTemplates parses the embedded templates and catalogs. They are loaded on first use; call
+// it at startup to fail fast on a broken template.
+func LoadEmailTemplates() error {
+	emailTemplatesOnce.Do(func() {
+		emailTemplates, emailTemplatesErr = loadEmailTemplates()
+	})
+	return emailTemplatesErr
+}
+
+func loadEmailTemplates() (*emailTemplateSet, error) {
+	set := &emailTemplateSet{
+		catalogs:  make(map[string]map[string]string),
+		templates: make(map[string]map[string]emailTemplate),
+	}
+
+	catalogFiles, err := fs.Glob(emailTemplateFiles, "templates/locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range catalogFiles {
+		data, err := emailTemplateFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		set.catalogs[strings.TrimSuffix(path.Base(file), ".json")] = catalog
+	}
+	if set.catalogs[DefaultLocale] == nil {
+		return nil, fmt.Errorf("missing %s translation catalog", DefaultLocale)
+	}
+
+	textFiles, err := fs.Glob(emailTemplateFiles, "templates/email/*.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range textFiles {
+		if name := strings.TrimSuffix(path.Base(file), ".txt"); name != "layout" {
+			set.names = append(set.names, name)
+		}
+	}
+	sort.Strings(set.names)
+
+	// Each locale gets its own parse, with "t" bound to its catalog
+	for locale := range set.catalogs {
+		funcs := set.funcs(locale)
+		set.templates[locale] = make(map[string]emailTemplate)
+
+		for _, name := range set.names {
+			text, err := texttemplate.New(name).Funcs(funcs).Option("missingkey=error").
+				ParseFS(emailTemplateFiles, "templates/email/layout.txt", "templates/email/"+name+".txt")
+			if err != nil {
+				return nil, err
+			}
+			html, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Option("missingkey=error").
+				ParseFS(emailTemplateFiles, "templates/email/layout.html", "templates/email/"+name+".html")
+			if err != nil {
+				return nil, err
+			}
+			set.templates[locale][name] = emailTemplate{text: text, html: html}
+		}
+	}
+
+	return set, nil
+}
+
+func (set *emailTemplateSet) funcs(locale string) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"t": func(key string, args ...interface{}) string {
+			return set.translate(locale, key, args...)
+		},
+		"link": emailLink,
+		"datetime": func(t time.Time) string {
+			return t.Format(set.translate(locale, "format.datetime"))
+		},
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"cta": func(path, label string) emailButton {
+			return emailButton{URL: emailLink(path), Label: label}
+		},
+		"field": func(label string, value interface{}) emailField {
+			return emailField{Label: label, Value: value}
+		},
+	}
+}
+
+// translate formats a catalog string with args, falling back to the default locale, then to the key
+func (set *emailTemplateSet) translate(locale, key string, args ...interface{}) string {
+	format, ok := set.catalogs[locale][key]
+	if !ok {
+		format, ok = set.catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// emailLink turns an app path into an absolute URL under the frontend URL
+func emailLink(path string) string {
+	return strings.TrimRight(config.AppConfig.FrontendURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// RenderEmail renders a template in a locale (falling back to DefaultLocale) as a subject and plain
+// text and HTML bodies. Values in data are available to the template by key, along with Locale.
+func RenderEmail(name, locale string, data map[string]interface{}) (EmailTemplate, error) {
+	if err := LoadEmailTemplates(); err != nil {
+		return EmailTemplate{}, err
+	}
+
+	locale = ResolveLocale(locale)
+	tmpl, ok := emailTemplates.templates[locale][name]
+	if !ok {
+		return EmailTemplate{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	values := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		values[key] = value
+	}
+	values["Locale"] = locale
+
+	var subject, text, html strings.Builder
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return EmailTemplate{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, "layout", values); err != nil {
+		return EmailTemplate{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", values); err != nil {
+		return EmailTemplate{}, err
+	}
+
+	return EmailTemplate{
+		// Subjects hold user input; keep them to one line so they can't add headers
+		Subject:  strings.Join(strings.Fields(subject.String()), " "),
+		Body:     text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
+
+// Translate returns a catalog string in a locale, formatted with args
+func Translate(locale, key string, args ...interface{}) string {
+	if err := LoadEmailTemplates(); err != nil {
+		return key
+	}
+	return emailTemplates.translate(ResolveLocale(locale), key, args...)
+}
+
+// EmailTemplateNames lists the available email templates
+func EmailTemplateNames() []string {
+	if err := LoadEmailTemplates(); err != nil {
+		return nil
+	}
+	return emailTemplates.names
+}
+
+// EmailLocales lists the locales with a translation catalog
+func EmailLocales() []string {
+	if err := LoadEmailTemplates(); err != nil {
+		return []string{DefaultLocale}
+	}
+
+	locales := make([]string, 0, len(emailTemplates.catalogs))
+	for locale := range emailTemplates.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// MatchLocale finds the supported locale for a language tag such as "es" or "es-MX"
+func MatchLocale(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	language, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+
+	for _, locale := range EmailLocales() {
+		if locale == tag || locale == language {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// ResolveLocale returns the supported locale for a language tag, or DefaultLocale
+func ResolveLocale(tag string) string {
+	if locale, ok := MatchLocale(tag); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// LocaleFromAcceptLanguage picks the first supported locale from an Accept-Language header
+func LocaleFromAcceptLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if locale, ok := MatchLocale(tag); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
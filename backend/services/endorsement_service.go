@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Endorsers listed per skill on skill responses
+const maxEndorsersPerSkill = 5
+
+var (
+	ErrEndorsementNotAllowed = errors.New("only the requester of a completed exchange on this skill can endorse it")
+	ErrAlreadyEndorsed       = errors.New("this exchange has already been used to endorse the skill")
+)
+
+type EndorsementService struct{}
+
+// Endorse records an endorsement of skillID backed by a completed exchange
+func (es *EndorsementService) Endorse(userID, skillID, exchangeID uint, comment string) (*models.Endorsement, error) {
+	var exchange models.Exchange
+	if err := config.DB.Preload("Skill").First(&exchange, exchangeID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrEndorsementNotAllowed
+		}
+		return nil, err
+	}
+
+	if exchange.SkillID != skillID || exchange.RequesterID != userID || exchange.Status != "completed" {
+		return nil, ErrEndorsementNotAllowed
+	}
+
+	var existing int64
+	config.DB.Model(&models.Endorsement{}).Where("exchange_id = ?", exchangeID).Count(&existing)
+	if existing > 0 {
+		return nil, ErrAlreadyEndorsed
+	}
+
+	endorsement := models.Endorsement{
+		ExchangeID: exchangeID,
+		SkillID:    skillID,
+		EndorserID: userID,
+		EndorseeID: exchange.Skill.UserID,
+		Comment:    comment,
+	}
+
+	// A concurrent request may have endorsed with the same exchange since the check above
+	result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&endorsement)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAlreadyEndorsed
+	}
+
+	return &endorsement, nil
+}
+
+// AttachEndorsements fills EndorsementCount and Endorsers on the given skills
+func (es *EndorsementService) AttachEndorsements(skills []models.Skill) {
+	if len(skills) == 0 {
+		return
+	}
+
+	skillIDs := make([]uint, len(skills))
+	for i, skill := range skills {
+		skillIDs[i] = skill.ID
+	}
+
+	var counts []struct {
+		SkillID uint
+		Count   int64
+	}
+	config.DB.Model(&models.Endorsement{}).
+		Select("skill_id, COUNT(*) AS count").
+		Where("skill_id IN ?", skillIDs).
+		Group("skill_id").
+		Scan(&counts)
+
+	countBySkill := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		countBySkill[row.SkillID] = row.Count
+	}
+
+	// Only the most recent endorsements of each skill
+	var latest []models.Endorsement
+	config.DB.Raw(`
+		SELECT * FROM (
+			SELECT endorsements.*, ROW_NUMBER() OVER (PARTITION BY skill_id ORDER BY created_at DESC, id DESC) AS position
+			FROM endorsements
+			WHERE skill_id IN ? AND deleted_at IS NULL
+		) ranked
+		WHERE position <= ?
+		ORDER BY skill_id, position`, skillIDs, maxEndorsersPerSkill).
+		Scan(&latest)
+	es.AttachEndorsers(latest)
+
+	endorsers := make(map[uint][]models.Endorser)
+	for _, endorsement := range latest {
+		endorsers[endorsement.SkillID] = append(endorsers[endorsement.SkillID], *endorsement.EndorsedBy)
+	}
+
+	for i := range skills {
+		skills[i].EndorsementCount = countBySkill[skills[i].ID]
+		skills[i].Endorsers = endorsers[skills[i].ID]
+	}
+}
+
+// AttachEndorsers loads the endorser of each endorsement and fills EndorsedBy with its summary
+func (es *EndorsementService) AttachEndorsers(endorsements []models.Endorsement) {
+	userIDs := make([]uint, len(endorsements))
+	for i, endorsement := range endorsements {
+		userIDs[i] = endorsement.EndorserID
+	}
+
+	var users []models.User
+	config.DB.Select("id", "username", "full_name", "avatar").Where("id IN ?", userIDs).Find(&users)
+	byID := make(map[uint]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	for i := range endorsements {
+		endorsements[i].Endorser = byID[endorsements[i].EndorserID]
+		endorser := models.NewEndorser(endorsements[i])
+		endorsements[i].EndorsedBy = &endorser
+	}
+}
+
+// CountEndorsements returns how many endorsements a skill had received before asOf
+func (es *EndorsementService) CountEndorsements(skillID uint, asOf time.Time) int64 {
+	var count int64
+	config.DB.Model(&models.Endorsement{}).Where("skill_id = ? AND created_at < ?", skillID, asOf).Count(&count)
+	return count
+}
+
+// EndorsementScore turns an endorsement count into a match score boost.
+// Logarithmic so the first few endorsements matter most; max 20 points.
+func (es *EndorsementService) EndorsementScore(count int64) int {
+	if count <= 0 {
+		return 0
+	}
+	return min(int(math.Round(8*math.Log2(float64(count)+1))), 20)
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+)
+
+// Buckets used for traffic allocation; 100 buckets per percent
+const experimentBuckets = 10000
+
+var ErrExperimentAlreadyRunning = errors.New("another experiment is already running")
+
+type ExperimentService struct{}
+
+// ExperimentAssignment is the variant a user was bucketed into
+type ExperimentAssignment struct {
+	ExperimentID uint   `json:"experiment_id"`
+	Experiment   string `json:"key"`
+	Variant      string `json:"variant"`
+}
+
+// VariantMetrics aggregates downstream outcomes for users exposed to one variant
+type VariantMetrics struct {
+	Variant          string  `json:"variant"`
+	Users            int64   `json:"users"`
+	Exposures        int64   `json:"exposures"`
+	ExchangeRequests int64   `json:"exchange_requests"`
+	Accepted         int64   `json:"accepted"` // Accepted or completed
+	Completed        int64   `json:"completed"`
+	Rejected         int64   `json:"rejected"`
+	RequestsPerUser  float64 `json:"requests_per_user"`
+	AcceptanceRate   float64 `json:"acceptance_rate"` // Accepted / requests
+}
+
+// ActiveExperiment returns the running matching experiment, if any
+func (es *ExperimentService) ActiveExperiment() *models.Experiment {
+	var experiment models.Experiment
+	if err := config.DB.Preload("Variants").Where("status = ?", "running").First(&experiment).Error; err != nil {
+		return nil
+	}
+	return &experiment
+}
+
+// Assign buckets a user into a variant. The same user always gets the same variant, and
+// changing the traffic percentage adds or removes users without reshuffling the others.
+func (es *ExperimentService) Assign(experiment *models.Experiment, userID uint) (*models.ExperimentVariant, bool) {
+	if experiment == nil || len(experiment.Variants) == 0 {
+		return nil, false
+	}
+
+	if experimentHash(experiment.Key, "traffic", userID)%experimentBuckets >= uint32(experiment.TrafficPercent*experimentBuckets/100) {
+		return nil, false
+	}
+
+	totalWeight := 0
+	for _, variant := range experiment.Variants {
+		totalWeight += variant.Weight
+	}
+	if totalWeight <= 0 {
+		return nil, false
+	}
+
+	point := int(experimentHash(experiment.Key, "variant", userID) % uint32(totalWeight))
+	for i := range experiment.Variants {
+		point -= experiment.Variants[i].Weight
+		if point < 0 {
+			return &experiment.Variants[i], true
+		}
+	}
+	return nil, false
+}
+
+// MatchServiceFor returns the match pipeline a user should get, plus their assignment if they're in an experiment
+func (es *ExperimentService) MatchServiceFor(userID uint) (*MatchService, *ExperimentAssignment) {
+	experiment := es.ActiveExperiment()
+	variant, ok := es.Assign(experiment, userID)
+	if !ok {
+		return &MatchService{}, nil
+	}
+
+	scoring, err := ParseMatchScoringConfig(experiment.Key+"/"+variant.Name, []byte(variant.Scoring))
+	if err != nil {
+		log.Printf("Experiment %s variant %s has invalid scoring, serving default: %v", experiment.Key, variant.Name, err)
+		return &MatchService{}, nil
+	}
+
+	return &MatchService{Scoring: &scoring}, &ExperimentAssignment{
+		ExperimentID: experiment.ID,
+		Experiment:   experiment.Key,
+		Variant:      variant.Name,
+	}
+}
+
+// LogExposure records that a variant served a matches response to the user
+func (es *ExperimentService) LogExposure(assignment ExperimentAssignment, userID uint, endpoint string, matchCount int) {
+	exposure := models.ExperimentExposure{
+		ExperimentID: assignment.ExperimentID,
+		Variant:      assignment.Variant,
+		UserID:       userID,
+		Endpoint:     endpoint,
+		MatchCount:   matchCount,
+	}
+	if err := config.DB.Create(&exposure).Error; err != nil {
+		log.Printf("Failed to log exposure for experiment %s: %v", assignment.Experiment, err)
+	}
+}
+
+// SetStatus starts or stops an experiment; only one experiment may run at a time
+func (es *ExperimentService) SetStatus(experiment *models.Experiment, status string) error {
+	now := time.Now()
+	updates := map[string]interface{}{"status": status}
+
+	switch status {
+	case "running":
+		var running int64
+		config.DB.Model(&models.Experiment{}).Where("status = ? AND id != ?", "running", experiment.ID).Count(&running)
+		if running > 0 {
+			return ErrExperimentAlreadyRunning
+		}
+		if experiment.StartedAt == nil {
+			updates["started_at"] = now
+		}
+		updates["stopped_at"] = nil
+	case "stopped":
+		updates["stopped_at"] = now
+	default:
+		return fmt.Errorf("invalid experiment status %q", status)
+	}
+
+	return config.DB.Model(experiment).Updates(updates).Error
+}
+
+// Metrics counts exposures and the exchanges exposed users requested after their first exposure
+func (es *ExperimentService) Metrics(experiment models.Experiment) ([]VariantMetrics, error) {
+	end := time.Now()
+	if experiment.StoppedAt != nil {
+		end = *experiment.StoppedAt
+	}
+
+	var exposureRows []struct {
+		Variant   string
+		Users     int64
+		Exposures int64
+	}
+	if err := config.DB.Model(&models.ExperimentExposure{}).
+		Select("variant, COUNT(DISTINCT user_id) AS users, COUNT(*) AS exposures").
+		Where("experiment_id = ?", experiment.ID).
+		Group("variant").
+		Scan(&exposureRows).Error; err != nil {
+		return nil, err
+	}
+
+	var outcomeRows []struct {
+		Variant string
+		Status  string
+		Count   int64
+	}
+	if err := config.DB.Raw(`
+		SELECT x.variant, e.status, COUNT(*) AS count
+		FROM exchanges e
+		JOIN (
+			SELECT user_id, variant, MIN(created_at) AS first_seen
+			FROM experiment_exposures
+			WHERE experiment_id = ?
+			GROUP BY user_id, variant
+		) x ON x.user_id = e.requester_id AND e.created_at >= x.first_seen
+		WHERE e.deleted_at IS NULL AND e.created_at <= ?
+		GROUP BY x.variant, e.status
+	`, experiment.ID, end).Scan(&outcomeRows).Error; err != nil {
+		return nil, err
+	}
+
+	// Report every configured variant, even ones nobody has seen yet
+	byVariant := make(map[string]*VariantMetrics)
+	var metrics []*VariantMetrics
+	for _, variant := range experiment.Variants {
+		m := &VariantMetrics{Variant: variant.Name}
+		byVariant[variant.Name] = m
+		metrics = append(metrics, m)
+	}
+	lookup := func(name string) *VariantMetrics {
+		if m, ok := byVariant[name]; ok {
+			return m
+		}
+		m := &VariantMetrics{Variant: name}
+		byVariant[name] = m
+		metrics = append(metrics, m)
+		return m
+	}
+
+	for _, row := range exposureRows {
+		m := lookup(row.Variant)
+		m.Users = row.Users
+		m.Exposures = row.Exposures
+	}
+	for _, row := range outcomeRows {
+		m := lookup(row.Variant)
+		m.ExchangeRequests += row.Count
+		switch row.Status {
+		case "accepted":
+			m.Accepted += row.Count
+		case "completed":
+			m.Accepted += row.Count
+			m.Completed += row.Count
+		case "rejected":
+			m.Rejected += row.Count
+		}
+	}
+
+	result := make([]VariantMetrics, len(metrics))
+	for i, m := range metrics {
+		if m.Users > 0 {
+			m.RequestsPerUser = float64(m.ExchangeRequests) / float64(m.Users)
+		}
+		if m.ExchangeRequests > 0 {
+			m.AcceptanceRate = float64(m.Accepted) / float64(m.ExchangeRequests)
+		}
+		result[i] = *m
+	}
+	return result, nil
+}
+
+func experimentHash(key, salt string, userID uint) uint32 {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%s:%d", key, salt, userID)
+	return h.Sum32()
+}
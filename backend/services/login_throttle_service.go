@@ -0,0 +1,152 @@
+package services
+
+import (
+	"math"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+)
+
+type LoginThrottleService struct{}
+
+// ThrottleStatus describes whether a login attempt may proceed
+type ThrottleStatus struct {
+	Allowed    bool
+	Locked     bool
+	Scope      string // Which counter blocked the attempt: ThrottleScopeAccount or ThrottleScopeIP
+	RetryAfter time.Duration
+}
+
+// Check reports whether a login for the given email and IP may be attempted right now.
+// Both the account and the IP counters are consulted and the stricter result wins.
+func (ts *LoginThrottleService) Check(email, ip string) ThrottleStatus {
+	status := ThrottleStatus{Allowed: true}
+
+	for _, throttle := range ts.loadThrottles(email, ip) {
+		current := ts.evaluate(throttle)
+		current.Scope = throttle.Scope
+		if !current.Allowed && current.RetryAfter > status.RetryAfter {
+			status = current
+		}
+	}
+
+	return status
+}
+
+// RecordFailure increments the account and IP counters after a failed login.
+// It returns true when this failure just locked the account.
+func (ts *LoginThrottleService) RecordFailure(email, ip string) (bool, time.Time) {
+	accountLocked, lockedUntil := ts.recordFailure(ThrottleScopeAccount, normalizeEmail(email), config.AppConfig.LoginMaxAccountFailures)
+	ts.recordFailure(ThrottleScopeIP, ip, config.AppConfig.LoginMaxIPFailures)
+	return accountLocked, lockedUntil
+}
+
+// RecordSuccess clears the account counter after a successful login.
+// The IP counter is left alone so a valid login can't mask guessing against other accounts.
+func (ts *LoginThrottleService) RecordSuccess(email string) {
+	config.DB.Where("scope = ? AND identifier = ?", ThrottleScopeAccount, normalizeEmail(email)).Delete(&models.LoginThrottle{})
+}
+
+// RecordAttempt writes an audit record for a login attempt
+func (ts *LoginThrottleService) RecordAttempt(email, ip, userAgent string, userID *uint, success bool, reason string) {
+	attempt := models.LoginAttempt{
+		Email:     normalizeEmail(email),
+		UserID:    userID,
+		IPAddress: ip,
+		UserAgent: userAgent,
+		Success:   success,
+		Reason:    reason,
+	}
+	config.DB.Create(&attempt)
+}
+
+func (ts *LoginThrottleService) loadThrottles(email, ip string) []models.LoginThrottle {
+	var throttles []models.LoginThrottle
+	config.DB.Where("(scope = ? AND identifier = ?) OR (scope = ? AND identifier = ?)",
+		ThrottleScopeAccount, normalizeEmail(email), ThrottleScopeIP, ip).Find(&throttles)
+	return throttles
+}
+
+func (ts *LoginThrottleService) evaluate(throttle models.LoginThrottle) ThrottleStatus {
+	now := time.Now()
+
+	// Hard lockout
+	if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+		return ThrottleStatus{Allowed: false, Locked: true, RetryAfter: throttle.LockedUntil.Sub(now)}
+	}
+
+	if throttle.FailureCount == 0 || throttle.LastFailureAt == nil {
+		return ThrottleStatus{Allowed: true}
+	}
+
+	// Exponential backoff between attempts
+	nextAllowed := throttle.LastFailureAt.Add(ts.backoff(throttle.FailureCount))
+	if nextAllowed.After(now) {
+		return ThrottleStatus{Allowed: false, RetryAfter: nextAllowed.Sub(now)}
+	}
+
+	return ThrottleStatus{Allowed: true}
+}
+
+// backoff returns base * 2^(failures-1), capped at the lockout duration
+func (ts *LoginThrottleService) backoff(failures int) time.Duration {
+	base := time.Duration(config.AppConfig.LoginBackoffBaseSeconds) * time.Second
+	maxBackoff := ts.lockoutDuration()
+
+	delay := time.Duration(float64(base) * math.Pow(2, float64(failures-1)))
+	if delay > maxBackoff || delay < 0 {
+		return maxBackoff
+	}
+	return delay
+}
+
+func (ts *LoginThrottleService) lockoutDuration() time.Duration {
+	return time.Duration(config.AppConfig.LoginLockoutMinutes) * time.Minute
+}
+
+func (ts *LoginThrottleService) recordFailure(scope, key string, maxFailures int) (bool, time.Time) {
+	var locked bool
+	var lockedUntil time.Time
+
+	config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Count the failure in one statement, so concurrent first failures for a key don't collide
+		// on the unique index. Failures older than the lockout window no longer count.
+		throttle := models.LoginThrottle{Scope: scope, Identifier: key, FailureCount: 1, LastFailureAt: &now}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "scope"}, {Name: "identifier"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failure_count": gorm.Expr("CASE WHEN login_throttles.last_failure_at IS NULL OR login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failure_count + 1 END",
+					now.Add(-ts.lockoutDuration())),
+				"last_failure_at": now,
+				"updated_at":      now,
+			}),
+		}, clause.Returning{}).Create(&throttle).Error; err != nil {
+			return err
+		}
+
+		if maxFailures > 0 && throttle.FailureCount >= maxFailures {
+			until := now.Add(ts.lockoutDuration())
+			locked = true
+			lockedUntil = until
+			return tx.Model(&throttle).Updates(map[string]interface{}{"locked_until": until, "failure_count": 0}).Error
+		}
+		return nil
+	})
+
+	return locked, lockedUntil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RelevanceJudgments maps a user to the offered skills they actually engaged with
+type RelevanceJudgments map[uint]*UserJudgments
+
+// UserJudgments is what one user went on to exchange in the evaluation window
+type UserJudgments struct {
+	Since  time.Time        // Creation of their first judged exchange; matches are replayed as of then
+	Grades map[uint]float64 // Offered skill ID to how well it went: 2 for a completed exchange, 1 for an accepted one
+}
+
+// MatchEvaluationResult holds ranking quality metrics for one scoring configuration
+type MatchEvaluationResult struct {
+	Config          string          `json:"config"`
+	Users           int             `json:"users"`
+	SkippedUsers    int             `json:"skipped_users"`    // Judged users whose accounts have since been deleted
+	UserCoverage    float64         `json:"user_coverage"`    // Share of users with a relevant skill anywhere in their matches
+	AverageMatches  float64         `json:"average_matches"`  // Mean number of distinct skills returned
+	PrecisionAtK    map[int]float64 `json:"precision_at_k"`   // Mean share of the top k that is relevant
+	RecallAtK       map[int]float64 `json:"recall_at_k"`      // Mean share of relevant skills found in the top k
+	NDCGAtK         map[int]float64 `json:"ndcg_at_k"`        // Mean graded NDCG of the top k
+	CatalogCoverage map[int]float64 `json:"catalog_coverage"` // Share of active offered skills shown in anyone's top k
+}
+
+// LoadRelevanceJudgments collects accepted and completed exchanges created since the given time
+func LoadRelevanceJudgments(since time.Time, maxUsers int) (RelevanceJudgments, error) {
+	var exchanges []models.Exchange
+	if err := config.DB.Where("status IN ? AND created_at >= ?", []string{"accepted", "completed"}, since).
+		Order("requester_id ASC, created_at ASC").
+		Find(&exchanges).Error; err != nil {
+		return nil, err
+	}
+
+	judgments := make(RelevanceJudgments)
+	for _, exchange := range exchanges {
+		judged := judgments[exchange.RequesterID]
+		if judged == nil {
+			if maxUsers > 0 && len(judgments) >= maxUsers {
+				continue
+			}
+			judged = &UserJudgments{Since: exchange.CreatedAt, Grades: make(map[uint]float64)}
+			judgments[exchange.RequesterID] = judged
+		}
+
+		grade := 1.0
+		if exchange.Status == "completed" {
+			grade = 2
+		}
+		judged.Grades[exchange.SkillID] = math.Max(judged.Grades[exchange.SkillID], grade)
+	}
+
+	return judgments, nil
+}
+
+// EvaluateMatchRanking runs FindAdvancedMatches with the given scoring for every judged user
+// and scores the ranked skills against what they actually went on to exchange. Matching is
+// replayed as of each user's first judged exchange, so the judged exchanges and everything that
+// followed them (reviews, endorsements, completion rates) can't leak into the scores. Users whose
+// accounts have been deleted since are skipped and counted in SkippedUsers.
+func EvaluateMatchRanking(scoring MatchScoringConfig, judgments RelevanceJudgments, ks []int) (*MatchEvaluationResult, error) {
+	result := &MatchEvaluationResult{
+		Config:          scoring.Name,
+		PrecisionAtK:    make(map[int]float64),
+		RecallAtK:       make(map[int]float64),
+		NDCGAtK:         make(map[int]float64),
+		CatalogCoverage: make(map[int]float64),
+	}
+
+	shown := make(map[int]map[uint]bool)
+	for _, k := range ks {
+		shown[k] = make(map[uint]bool)
+	}
+
+	usersWithHits, totalMatches := 0, 0
+
+	// Iterate in a fixed order so runs are comparable
+	userIDs := make([]uint, 0, len(judgments))
+	for userID := range judgments {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	for _, userID := range userIDs {
+		judged := judgments[userID]
+		relevant := judged.Grades
+		ms := &MatchService{Scoring: &scoring, AsOf: &judged.Since}
+		matches, err := ms.FindAdvancedMatches(userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result.SkippedUsers++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		ranked := rankedSkillIDs(matches)
+		totalMatches += len(ranked)
+		for _, skillID := range ranked {
+			if relevant[skillID] > 0 {
+				usersWithHits++
+				break
+			}
+		}
+
+		for _, k := range ks {
+			top := ranked[:min(k, len(ranked))]
+			hits := 0
+			for _, skillID := range top {
+				shown[k][skillID] = true
+				if relevant[skillID] > 0 {
+					hits++
+				}
+			}
+			result.PrecisionAtK[k] += float64(hits) / float64(k)
+			result.RecallAtK[k] += float64(hits) / float64(len(relevant))
+			result.NDCGAtK[k] += ndcg(top, relevant, k)
+		}
+	}
+
+	result.Users = len(userIDs) - result.SkippedUsers
+	if result.Users == 0 {
+		return result, nil
+	}
+
+	users := float64(result.Users)
+	result.UserCoverage = float64(usersWithHits) / users
+	result.AverageMatches = float64(totalMatches) / users
+
+	var activeSkills int64
+	config.DB.Model(&models.Skill{}).Where("skill_type = ? AND is_active = ?", "offering", true).Count(&activeSkills)
+
+	for _, k := range ks {
+		result.PrecisionAtK[k] /= users
+		result.RecallAtK[k] /= users
+		result.NDCGAtK[k] /= users
+		if activeSkills > 0 {
+			result.CatalogCoverage[k] = float64(len(shown[k])) / float64(activeSkills)
+		}
+	}
+
+	return result, nil
+}
+
+// rankedSkillIDs returns offered skill IDs in rank order, keeping the first occurrence of each
+func rankedSkillIDs(matches []AdvancedMatch) []uint {
+	seen := make(map[uint]bool)
+	var ids []uint
+	for _, match := range matches {
+		if !seen[match.OfferedSkillID] {
+			seen[match.OfferedSkillID] = true
+			ids = append(ids, match.OfferedSkillID)
+		}
+	}
+	return ids
+}
+
+func ndcg(top []uint, relevant map[uint]float64, k int) float64 {
+	dcg := 0.0
+	for i, skillID := range top {
+		dcg += (math.Pow(2, relevant[skillID]) - 1) / math.Log2(float64(i+2))
+	}
+
+	grades := make([]float64, 0, len(relevant))
+	for _, grade := range relevant {
+		grades = append(grades, grade)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(grades)))
+
+	idcg := 0.0
+	for i, grade := range grades[:min(k, len(grades))] {
+		idcg += (math.Pow(2, grade) - 1) / math.Log2(float64(i+2))
+	}
+
+	if idcg == 0 {
+		return 0
+	}
+	return dcg / idcg
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Points per like/dismiss in a category, and the cap on the total adjustment
+const (
+	feedbackCategoryWeight = 3
+	feedbackCategoryCap    = 12
+)
+
+type MatchFeedbackService struct{}
+
+// SetFeedback records the user's feedback on an offered skill, replacing any earlier feedback on it
+func (fs *MatchFeedbackService) SetFeedback(userID uint, skill models.Skill, action string, snoozeDays int) (*models.MatchFeedback, error) {
+	feedback := models.MatchFeedback{
+		UserID:         userID,
+		OfferedSkillID: skill.ID,
+		Action:         action,
+		Category:       skill.Category,
+	}
+	if action == "snooze" {
+		until := time.Now().AddDate(0, 0, snoozeDays)
+		feedback.SnoozedUntil = &until
+	}
+
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "offered_skill_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"action", "category", "snoozed_until", "updated_at"}),
+	}).Create(&feedback).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &feedback, nil
+}
+
+// RemoveFeedback clears the user's feedback on an offered skill
+func (fs *MatchFeedbackService) RemoveFeedback(userID, skillID uint) error {
+	return config.DB.Where("user_id = ? AND offered_skill_id = ?", userID, skillID).Delete(&models.MatchFeedback{}).Error
+}
+
+// HiddenSkillIDs returns offered skills the user had dismissed or was snoozing at asOf
+func (fs *MatchFeedbackService) HiddenSkillIDs(userID uint, asOf time.Time) map[uint]bool {
+	var skillIDs []uint
+	config.DB.Model(&models.MatchFeedback{}).
+		Where("user_id = ? AND updated_at < ? AND (action = ? OR (action = ? AND snoozed_until > ?))", userID, asOf, "dismiss", "snooze", asOf).
+		Pluck("offered_skill_id", &skillIDs)
+
+	hidden := make(map[uint]bool, len(skillIDs))
+	for _, id := range skillIDs {
+		hidden[id] = true
+	}
+	return hidden
+}
+
+// CategoryAdjustments turns likes and dismissals given before asOf into a per-category score adjustment
+func (fs *MatchFeedbackService) CategoryAdjustments(userID uint, asOf time.Time) map[string]int {
+	var rows []struct {
+		Category string
+		Action   string
+		Count    int
+	}
+	config.DB.Model(&models.MatchFeedback{}).
+		Select("category, action, COUNT(*) AS count").
+		Where("user_id = ? AND action IN ? AND updated_at < ?", userID, []string{"like", "dismiss"}, asOf).
+		Group("category, action").
+		Scan(&rows)
+
+	adjustments := make(map[string]int)
+	for _, row := range rows {
+		if row.Action == "like" {
+			adjustments[row.Category] += row.Count * feedbackCategoryWeight
+		} else {
+			adjustments[row.Category] -= row.Count * feedbackCategoryWeight
+		}
+	}
+
+	for category, adjustment := range adjustments {
+		adjustments[category] = max(min(adjustment, feedbackCategoryCap), -feedbackCategoryCap)
+	}
+	return adjustments
+}
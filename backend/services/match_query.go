@@ -0,0 +1,205 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidMatchCursor = errors.New("invalid cursor")
+
+// MatchSortOrders lists the supported sort values for match listings
+var MatchSortOrders = []string{"score", "rating", "activity", "completion", "location"}
+
+// MatchFilters narrows a list of matches
+type MatchFilters struct {
+	MinScore        int
+	Category        string // Offered skill's category
+	Level           string // Offered skill's level
+	SeekingCategory string
+	SeekingLevel    string
+	OfferedSkillID  uint
+	MutualOnly      bool
+	Location        string // Case-insensitive substring of the matched user's location as their privacy settings show it
+}
+
+// matchCursor identifies the last match of a page by its sort keys
+type matchCursor struct {
+	Sort           string  `json:"s"`
+	MatchScore     int     `json:"sc"`
+	UserRating     float64 `json:"r"`
+	ActivityScore  int     `json:"a"`
+	CompletionRate float64 `json:"c"`
+	LocationScore  int     `json:"l"`
+	MutualInterest bool    `json:"m"`
+	UserID         uint    `json:"u"`
+	OfferedSkillID uint    `json:"o"`
+	SeekingSkillID uint    `json:"k"`
+}
+
+// Apply returns the matches that pass every set filter
+func (mf MatchFilters) Apply(matches []AdvancedMatch) []AdvancedMatch {
+	profileService := &ProfileService{}
+	location := strings.ToLower(strings.TrimSpace(mf.Location))
+	visibleLocations := make(map[uint]string)
+
+	var filtered []AdvancedMatch
+	for _, match := range matches {
+		if match.MatchScore < mf.MinScore {
+			continue
+		}
+		if mf.Category != "" && !strings.EqualFold(match.OfferedCategory, mf.Category) {
+			continue
+		}
+		if mf.Level != "" && match.OfferedLevel != mf.Level {
+			continue
+		}
+		if mf.SeekingCategory != "" && !strings.EqualFold(match.SeekingCategory, mf.SeekingCategory) {
+			continue
+		}
+		if mf.SeekingLevel != "" && match.SeekingLevel != mf.SeekingLevel {
+			continue
+		}
+		if mf.OfferedSkillID != 0 && match.OfferedSkillID != mf.OfferedSkillID {
+			continue
+		}
+		if mf.MutualOnly && !match.MutualInterest {
+			continue
+		}
+		if location != "" {
+			visible, ok := visibleLocations[match.UserID]
+			if !ok {
+				settings := profileService.GetPrivacySettings(match.UserID)
+				visible = strings.ToLower(profileService.applyLocationPrecision(match.userLocation, settings.LocationPrecision))
+				visibleLocations[match.UserID] = visible
+			}
+			if !strings.Contains(visible, location) {
+				continue
+			}
+		}
+		filtered = append(filtered, match)
+	}
+
+	return filtered
+}
+
+// IsValidMatchSort reports whether sortBy is a supported sort order
+func IsValidMatchSort(sortBy string) bool {
+	for _, order := range MatchSortOrders {
+		if order == sortBy {
+			return true
+		}
+	}
+	return false
+}
+
+// SortMatches orders matches by the given key. Ties are broken by the other signals and finally
+// by IDs, so the order is total and stable across requests.
+func SortMatches(matches []AdvancedMatch, sortBy string) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matchBefore(sortBy, matchCursorFor(sortBy, matches[i]), matchCursorFor(sortBy, matches[j]))
+	})
+}
+
+// PageMatches returns up to limit matches after the cursor (from the start when empty) and the
+// cursor for the next page, which is empty on the last page. Matches must already be sorted by sortBy.
+func PageMatches(matches []AdvancedMatch, sortBy, cursor string, limit int) ([]AdvancedMatch, string, error) {
+	start := 0
+	if cursor != "" {
+		after, err := decodeMatchCursor(cursor)
+		if err != nil || after.Sort != sortBy {
+			return nil, "", ErrInvalidMatchCursor
+		}
+
+		start = sort.Search(len(matches), func(i int) bool {
+			return matchBefore(sortBy, after, matchCursorFor(sortBy, matches[i]))
+		})
+	}
+
+	end := min(start+limit, len(matches))
+	page := matches[start:end]
+
+	next := ""
+	if end < len(matches) && len(page) > 0 {
+		next = encodeMatchCursor(matchCursorFor(sortBy, page[len(page)-1]))
+	}
+	return page, next, nil
+}
+
+func matchCursorFor(sortBy string, match AdvancedMatch) matchCursor {
+	return matchCursor{
+		Sort:           sortBy,
+		MatchScore:     match.MatchScore,
+		UserRating:     match.UserRating,
+		ActivityScore:  match.ActivityScore,
+		CompletionRate: match.CompletionRate,
+		LocationScore:  match.LocationScore,
+		MutualInterest: match.MutualInterest,
+		UserID:         match.UserID,
+		OfferedSkillID: match.OfferedSkillID,
+		SeekingSkillID: match.SeekingSkillID,
+	}
+}
+
+// matchBefore reports whether a sorts strictly before b
+func matchBefore(sortBy string, a, b matchCursor) bool {
+	// Primary key for the chosen sort, higher first
+	switch sortBy {
+	case "rating":
+		if a.UserRating != b.UserRating {
+			return a.UserRating > b.UserRating
+		}
+	case "activity":
+		if a.ActivityScore != b.ActivityScore {
+			return a.ActivityScore > b.ActivityScore
+		}
+	case "completion":
+		if a.CompletionRate != b.CompletionRate {
+			return a.CompletionRate > b.CompletionRate
+		}
+	case "location":
+		if a.LocationScore != b.LocationScore {
+			return a.LocationScore > b.LocationScore
+		}
+	}
+
+	// Then the default ranking: score, rating, mutual interest, activity
+	if a.MatchScore != b.MatchScore {
+		return a.MatchScore > b.MatchScore
+	}
+	if a.UserRating != b.UserRating {
+		return a.UserRating > b.UserRating
+	}
+	if a.MutualInterest != b.MutualInterest {
+		return a.MutualInterest
+	}
+	if a.ActivityScore != b.ActivityScore {
+		return a.ActivityScore > b.ActivityScore
+	}
+
+	// Finally IDs, so no two matches compare equal
+	if a.UserID != b.UserID {
+		return a.UserID < b.UserID
+	}
+	if a.OfferedSkillID != b.OfferedSkillID {
+		return a.OfferedSkillID < b.OfferedSkillID
+	}
+	return a.SeekingSkillID < b.SeekingSkillID
+}
+
+func encodeMatchCursor(cursor matchCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeMatchCursor(value string) (matchCursor, error) {
+	var cursor matchCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// MatchScoringConfig holds the tunable weights of the advanced matching pipeline
+type MatchScoringConfig struct {
+	Name                 string  `json:"name"`
+	MinScore             int     `json:"min_score"`             // Seeking matches must score above this
+	RatingWeight         float64 `json:"rating_weight"`         // Points per star of the teacher's average rating
+	LocationWeight       float64 `json:"location_weight"`       // Multiplier on the location score
+	ActivityWeight       float64 `json:"activity_weight"`       // Multiplier on the activity score
+	CompletionWeight     float64 `json:"completion_weight"`     // Points for a 100% completion rate
+	EndorsementWeight    float64 `json:"endorsement_weight"`    // Multiplier on the endorsement score
+	MutualInterestBonus  int     `json:"mutual_interest_bonus"` // Added when both users want something the other offers
+	MutualMatchBonus     int     `json:"mutual_match_bonus"`    // Added to matches found by mutual matching
+	RecommendationWeight float64 `json:"recommendation_weight"` // Multiplier on the recommendation score
+	UseRankingModel      bool    `json:"use_ranking_model"`     // Use the trained model for recommendation scores when loaded
+	MaxMatchesPerUser    int     `json:"max_matches_per_user"`  // Diversity cap
+}
+
+// DefaultMatchScoring is the production scoring configuration
+var DefaultMatchScoring = MatchScoringConfig{
+	Name:                 "default",
+	MinScore:             20,
+	RatingWeight:         5,
+	LocationWeight:       1,
+	ActivityWeight:       1,
+	CompletionWeight:     20,
+	EndorsementWeight:    1,
+	MutualInterestBonus:  30,
+	MutualMatchBonus:     35,
+	RecommendationWeight: 1,
+	UseRankingModel:      true,
+	MaxMatchesPerUser:    2,
+}
+
+// LoadMatchScoringConfig reads a scoring configuration from JSON; omitted fields keep their defaults
+func LoadMatchScoringConfig(path string) (MatchScoringConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DefaultMatchScoring, err
+	}
+	return ParseMatchScoringConfig(path, data)
+}
+
+// ParseMatchScoringConfig applies JSON overrides to the default scoring; empty data means defaults
+func ParseMatchScoringConfig(name string, data []byte) (MatchScoringConfig, error) {
+	scoring := DefaultMatchScoring
+	scoring.Name = name
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &scoring); err != nil {
+			return scoring, fmt.Errorf("invalid scoring config %s: %w", name, err)
+		}
+	}
+	if scoring.MaxMatchesPerUser < 1 {
+		return scoring, fmt.Errorf("invalid scoring config %s: max_matches_per_user must be at least 1", name)
+	}
+
+	return scoring, nil
+}
+
+func (ms *MatchService) scoring() *MatchScoringConfig {
+	if ms.Scoring == nil {
+		return &DefaultMatchScoring
+	}
+	return ms.Scoring
+}
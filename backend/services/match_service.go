@@ -8,9 +8,15 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
-type MatchService struct{}
+// MatchService finds and ranks matches; a nil Scoring uses DefaultMatchScoring
+type MatchService struct {
+	Scoring *MatchScoringConfig
+	AsOf    *time.Time // Replays matching with the data that existed at this time; nil means now
+}
 
 type AdvancedMatch struct {
 	models.Match
@@ -21,6 +27,33 @@ type AdvancedMatch struct {
 	ResponseTime        string  `json:"response_time"`
 	MutualInterest      bool    `json:"mutual_interest"`
 	RecommendationScore int     `json:"recommendation_score"`
+	EndorsementCount    int64   `json:"endorsement_count"`
+	EndorsementScore    int     `json:"endorsement_score"`
+	OfferedCategory     string  `json:"offered_category"`
+	OfferedLevel        string  `json:"offered_level"`
+	SeekingCategory     string  `json:"seeking_category"`
+	SeekingLevel        string  `json:"seeking_level"`
+
+	userLocation string // Matched user's raw location, only used for filtering
+}
+
+// seekerSignals is what the current user's history and explicit interest say about their preferences.
+// It's loaded once per request and shared by every candidate.
+type seekerSignals struct {
+	exchangeCategories   map[string]int // Exchanges the user requested, per category
+	seekingLevels        map[string]int // The user's seeking skills, per level
+	bookmarkedSkills     map[uint]bool
+	followedUsers        map[uint]bool
+	bookmarkedCategories map[string]int // Bookmarked skills per category
+	categoryFeedback     map[string]int // Points from likes and dismissals of earlier matches, per category
+	modelScores          map[uint]int   // Ranking model points per offered skill; nil without a model
+}
+
+// matchCandidate is an offered skill that may serve one of the current user's seeking skills
+type matchCandidate struct {
+	seekingSkill models.Skill
+	offeredSkill models.Skill
+	mutual       bool // Found by mutual matching: each side offers something the other seeks
 }
 
 func (ms *MatchService) FindMatches(userID uint) ([]models.Match, error) {
@@ -49,14 +82,15 @@ func (ms *MatchService) FindAdvancedMatches(userID uint) ([]AdvancedMatch, error
 
 	// Get user's seeking skills
 	var userSeekingSkills []models.Skill
-	if err := config.DB.Where("user_id = ? AND skill_type = ? AND is_active = ?", userID, "seeking", true).Find(&userSeekingSkills).Error; err != nil {
+	if err := ms.skills().Where("user_id = ? AND skill_type = ? AND is_active = ?", userID, "seeking", true).Find(&userSeekingSkills).Error; err != nil {
 		return matches, err
 	}
 
 	// For each seeking skill, find users who offer similar skills
+	var candidates []matchCandidate
 	for _, seekingSkill := range userSeekingSkills {
 		var offeredSkills []models.Skill
-		query := config.DB.Preload("User").Preload("User.UserRating").Where("skill_type = ? AND is_active = ? AND user_id != ?", "offering", true, userID)
+		query := ms.skills().Preload("User").Preload("User.UserRating").Where("skill_type = ? AND is_active = ? AND user_id != ?", "offering", true, userID)
 
 		// Enhanced category matching with fuzzy logic
 		categoryMatches := ms.getCategoryMatches(seekingSkill.Category)
@@ -74,21 +108,48 @@ func (ms *MatchService) FindAdvancedMatches(userID uint) ([]AdvancedMatch, error
 			continue
 		}
 
-		// Calculate enhanced match scores
 		for _, offeredSkill := range offeredSkills {
-			advancedMatch := ms.calculateAdvancedMatchScore(currentUser, seekingSkill, offeredSkill)
-			if advancedMatch.MatchScore > 20 { // Higher threshold for quality matches
-				matches = append(matches, advancedMatch)
-			}
+			candidates = append(candidates, matchCandidate{seekingSkill: seekingSkill, offeredSkill: offeredSkill})
 		}
 	}
 
 	// Find mutual matches with enhanced scoring
-	mutualMatches, err := ms.findAdvancedMutualMatches(currentUser)
+	mutualCandidates, err := ms.findAdvancedMutualCandidates(currentUser)
 	if err == nil {
-		matches = append(matches, mutualMatches...)
+		candidates = append(candidates, mutualCandidates...)
 	}
 
+	signals := ms.loadSeekerSignals(userID)
+
+	// Score every candidate with the ranking model at once rather than one at a time
+	if ms.scoring().UseRankingModel && len(candidates) > 0 {
+		seen := make(map[uint]bool)
+		var offeredSkills []models.Skill
+		for _, candidate := range candidates {
+			if !seen[candidate.offeredSkill.ID] {
+				seen[candidate.offeredSkill.ID] = true
+				offeredSkills = append(offeredSkills, candidate.offeredSkill)
+			}
+		}
+		rankingService := &RankingService{}
+		signals.modelScores = rankingService.ScoreAll(currentUser, offeredSkills, ms.now())
+	}
+
+	// Calculate enhanced match scores
+	for _, candidate := range candidates {
+		advancedMatch := ms.calculateAdvancedMatchScore(currentUser, signals, candidate.seekingSkill, candidate.offeredSkill)
+		if candidate.mutual {
+			advancedMatch.MatchScore += ms.scoring().MutualMatchBonus // Higher bonus for mutual match
+			advancedMatch.MutualInterest = true
+			matches = append(matches, advancedMatch)
+		} else if advancedMatch.MatchScore > ms.scoring().MinScore { // Higher threshold for quality matches
+			matches = append(matches, advancedMatch)
+		}
+	}
+
+	// Drop matches the user dismissed or snoozed
+	matches = ms.applyFeedbackFilter(matches, userID)
+
 	// Remove duplicates and apply advanced sorting
 	matches = ms.removeDuplicateAdvancedMatches(matches)
 	matches = ms.applyMLRanking(matches, currentUser)
@@ -96,7 +157,97 @@ func (ms *MatchService) FindAdvancedMatches(userID uint) ([]AdvancedMatch, error
 	return matches, nil
 }
 
-func (ms *MatchService) calculateAdvancedMatchScore(currentUser models.User, seekingSkill, offeredSkill models.Skill) AdvancedMatch {
+// FindLearners is the reverse of FindAdvancedMatches: it ranks users whose seeking skills
+// the given user's offered skills can serve, using the same category and level compatibility
+func (ms *MatchService) FindLearners(userID uint) ([]AdvancedMatch, error) {
+	var matches []AdvancedMatch
+
+	var currentUser models.User
+	if err := config.DB.Preload("UserRating").First(&currentUser, userID).Error; err != nil {
+		return matches, err
+	}
+
+	var userOfferedSkills []models.Skill
+	if err := ms.skills().Where("user_id = ? AND skill_type = ? AND is_active = ?", userID, "offering", true).Find(&userOfferedSkills).Error; err != nil {
+		return matches, err
+	}
+
+	// For each offered skill, find users seeking something it can teach
+	for _, offeredSkill := range userOfferedSkills {
+		var seekingSkills []models.Skill
+		if err := ms.skills().Preload("User").Preload("User.UserRating").
+			Where("skill_type = ? AND is_active = ? AND user_id != ?", "seeking", true, userID).
+			Where("category IN ? AND level IN ?", ms.getSeekingCategories(offeredSkill.Category), ms.getSeekingLevels(offeredSkill.Level)).
+			Find(&seekingSkills).Error; err != nil {
+			continue
+		}
+
+		for _, seekingSkill := range seekingSkills {
+			learnerMatch := ms.calculateLearnerMatchScore(currentUser, offeredSkill, seekingSkill)
+			if learnerMatch.MatchScore > ms.scoring().MinScore {
+				matches = append(matches, learnerMatch)
+			}
+		}
+	}
+
+	matches = ms.removeDuplicateAdvancedMatches(matches)
+	matches = ms.applyMLRanking(matches, currentUser)
+
+	return matches, nil
+}
+
+// calculateLearnerMatchScore scores a learner for one of the current user's offered skills.
+// The match's user is the learner; offered skill is the current user's.
+func (ms *MatchService) calculateLearnerMatchScore(currentUser models.User, offeredSkill, seekingSkill models.Skill) AdvancedMatch {
+	scoring := ms.scoring()
+	learner := seekingSkill.User
+
+	learnerMatch := AdvancedMatch{
+		Match: models.Match{
+			UserID:         learner.ID,
+			UserName:       learner.FullName,
+			UserAvatar:     learner.Avatar,
+			OfferedSkillID: offeredSkill.ID,
+			OfferedSkill:   offeredSkill.Title,
+			SeekingSkillID: seekingSkill.ID,
+			SeekingSkill:   seekingSkill.Title,
+			MatchScore:     ms.calculateMatchScore(seekingSkill, offeredSkill),
+		},
+		OfferedCategory: offeredSkill.Category,
+		OfferedLevel:    offeredSkill.Level,
+		SeekingCategory: seekingSkill.Category,
+		SeekingLevel:    seekingSkill.Level,
+		userLocation:    learner.Location,
+	}
+
+	// Reliable learners (well reviewed, finish what they start) rank higher
+	if rating, ok := ms.averageRating(learner); ok {
+		learnerMatch.UserRating = rating
+		learnerMatch.MatchScore += int(rating * scoring.RatingWeight)
+	}
+
+	learnerMatch.LocationScore = ms.calculateLocationScore(currentUser.Location, learner.Location)
+	learnerMatch.MatchScore += int(float64(learnerMatch.LocationScore) * scoring.LocationWeight)
+
+	learnerMatch.ActivityScore = ms.calculateActivityScore(learner.ID)
+	learnerMatch.MatchScore += int(float64(learnerMatch.ActivityScore) * scoring.ActivityWeight)
+
+	learnerMatch.CompletionRate = ms.calculateCompletionRate(learner.ID)
+	learnerMatch.MatchScore += int(learnerMatch.CompletionRate * scoring.CompletionWeight)
+
+	learnerMatch.ResponseTime = ms.estimateResponseTime(learner.ID)
+
+	// Mutual when the learner also offers something the current user is seeking
+	learnerMatch.MutualInterest = ms.checkMutualInterest(currentUser.ID, learner.ID)
+	if learnerMatch.MutualInterest {
+		learnerMatch.MatchScore += scoring.MutualInterestBonus
+	}
+
+	return learnerMatch
+}
+
+func (ms *MatchService) calculateAdvancedMatchScore(currentUser models.User, signals *seekerSignals, seekingSkill, offeredSkill models.Skill) AdvancedMatch {
+	scoring := ms.scoring()
 	baseScore := ms.calculateMatchScore(seekingSkill, offeredSkill)
 
 	advancedMatch := AdvancedMatch{
@@ -110,79 +261,159 @@ func (ms *MatchService) calculateAdvancedMatchScore(currentUser models.User, see
 			SeekingSkill:   seekingSkill.Title,
 			MatchScore:     baseScore,
 		},
+		OfferedCategory: offeredSkill.Category,
+		OfferedLevel:    offeredSkill.Level,
+		SeekingCategory: seekingSkill.Category,
+		SeekingLevel:    seekingSkill.Level,
+		userLocation:    offeredSkill.User.Location,
 	}
 
 	// User rating boost
-	if offeredSkill.User.UserRating != nil {
-		advancedMatch.UserRating = offeredSkill.User.UserRating.AverageRating
-		ratingBoost := int(offeredSkill.User.UserRating.AverageRating * 5) // Max 25 points
+	if rating, ok := ms.averageRating(offeredSkill.User); ok {
+		advancedMatch.UserRating = rating
+		ratingBoost := int(rating * scoring.RatingWeight) // Max 25 points by default
 		advancedMatch.MatchScore += ratingBoost
 	}
 
 	// Location proximity score
 	advancedMatch.LocationScore = ms.calculateLocationScore(currentUser.Location, offeredSkill.User.Location)
-	advancedMatch.MatchScore += advancedMatch.LocationScore
+	advancedMatch.MatchScore += int(float64(advancedMatch.LocationScore) * scoring.LocationWeight)
 
 	// User activity score
 	advancedMatch.ActivityScore = ms.calculateActivityScore(offeredSkill.UserID)
-	advancedMatch.MatchScore += advancedMatch.ActivityScore
+	advancedMatch.MatchScore += int(float64(advancedMatch.ActivityScore) * scoring.ActivityWeight)
 
 	// Completion rate
 	advancedMatch.CompletionRate = ms.calculateCompletionRate(offeredSkill.UserID)
-	completionBoost := int(advancedMatch.CompletionRate * 20) // Max 20 points
+	completionBoost := int(advancedMatch.CompletionRate * scoring.CompletionWeight) // Max 20 points by default
 	advancedMatch.MatchScore += completionBoost
 
+	// Endorsements from past exchange partners on this specific skill
+	endorsementService := &EndorsementService{}
+	advancedMatch.EndorsementCount = endorsementService.CountEndorsements(offeredSkill.ID, ms.now())
+	advancedMatch.EndorsementScore = endorsementService.EndorsementScore(advancedMatch.EndorsementCount)
+	advancedMatch.MatchScore += int(float64(advancedMatch.EndorsementScore) * scoring.EndorsementWeight)
+
 	// Response time estimation
 	advancedMatch.ResponseTime = ms.estimateResponseTime(offeredSkill.UserID)
 
 	// Check for mutual interest
 	advancedMatch.MutualInterest = ms.checkMutualInterest(currentUser.ID, offeredSkill.UserID)
 	if advancedMatch.MutualInterest {
-		advancedMatch.MatchScore += 30 // Significant boost for mutual interest
+		advancedMatch.MatchScore += scoring.MutualInterestBonus // Significant boost for mutual interest
 	}
 
 	// ML-based recommendation score
-	advancedMatch.RecommendationScore = ms.calculateMLRecommendationScore(currentUser, offeredSkill)
-	advancedMatch.MatchScore += advancedMatch.RecommendationScore
+	advancedMatch.RecommendationScore = ms.calculateMLRecommendationScore(currentUser, signals, offeredSkill)
+	advancedMatch.MatchScore += int(float64(advancedMatch.RecommendationScore) * scoring.RecommendationWeight)
 
 	return advancedMatch
 }
 
+// Related categories for each category; a seeking skill can match offers in any of them
+var relatedCategories = map[string][]string{
+	"Programming":     {"Programming", "Web Development", "Software Development", "Tech"},
+	"Web Development": {"Web Development", "Programming", "Frontend", "Backend", "Fullstack"},
+	"Design":          {"Design", "UI/UX", "Graphics", "Creative"},
+	"Music":           {"Music", "Audio", "Sound", "Performance"},
+	"Language":        {"Language", "Communication", "Writing", "Translation"},
+	"Business":        {"Business", "Marketing", "Management", "Entrepreneurship"},
+	"Art":             {"Art", "Creative", "Visual", "Crafts"},
+	"Sports":          {"Sports", "Fitness", "Health", "Physical"},
+	"Cooking":         {"Cooking", "Food", "Culinary", "Baking"},
+	"Photography":     {"Photography", "Visual", "Creative", "Media"},
+}
+
+// Offered levels that suit a learner at each seeking level
+var compatibleLevels = map[string][]string{
+	"beginner":     {"beginner", "intermediate"},
+	"intermediate": {"intermediate", "advanced"},
+	"advanced":     {"intermediate", "advanced", "expert"},
+	"expert":       {"advanced", "expert"},
+}
+
 func (ms *MatchService) getCategoryMatches(category string) []string {
 	// Enhanced category matching with related categories
-	categoryMap := map[string][]string{
-		"Programming":     {"Programming", "Web Development", "Software Development", "Tech"},
-		"Web Development": {"Web Development", "Programming", "Frontend", "Backend", "Fullstack"},
-		"Design":          {"Design", "UI/UX", "Graphics", "Creative"},
-		"Music":           {"Music", "Audio", "Sound", "Performance"},
-		"Language":        {"Language", "Communication", "Writing", "Translation"},
-		"Business":        {"Business", "Marketing", "Management", "Entrepreneurship"},
-		"Art":             {"Art", "Creative", "Visual", "Crafts"},
-		"Sports":          {"Sports", "Fitness", "Health", "Physical"},
-		"Cooking":         {"Cooking", "Food", "Culinary", "Baking"},
-		"Photography":     {"Photography", "Visual", "Creative", "Media"},
-	}
-
-	if matches, exists := categoryMap[category]; exists {
+	if matches, exists := relatedCategories[category]; exists {
 		return matches
 	}
 	return []string{category}
 }
 
 func (ms *MatchService) getCompatibleLevels(userLevel string) []string {
-	levelMap := map[string][]string{
-		"beginner":     {"beginner", "intermediate"},
-		"intermediate": {"intermediate", "advanced"},
-		"advanced":     {"intermediate", "advanced", "expert"},
-		"expert":       {"advanced", "expert"},
-	}
-
-	if levels, exists := levelMap[userLevel]; exists {
+	if levels, exists := compatibleLevels[userLevel]; exists {
 		return levels
 	}
 	return []string{userLevel}
 }
 
+// getSeekingCategories is the reverse of getCategoryMatches: seeking categories an offer in this category can serve
+func (ms *MatchService) getSeekingCategories(offeredCategory string) []string {
+	categories := []string{offeredCategory}
+	for category := range relatedCategories {
+		if category != offeredCategory && containsString(ms.getCategoryMatches(category), offeredCategory) {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// getSeekingLevels is the reverse of getCompatibleLevels: seeking levels an offer at this level can serve
+func (ms *MatchService) getSeekingLevels(offeredLevel string) []string {
+	levels := []string{}
+	for level := range compatibleLevels {
+		if containsString(ms.getCompatibleLevels(level), offeredLevel) {
+			levels = append(levels, level)
+		}
+	}
+	if len(levels) == 0 {
+		levels = append(levels, offeredLevel)
+	}
+	sort.Strings(levels)
+	return levels
+}
+
+// now is the time matching runs at: AsOf when replaying, otherwise the current time
+func (ms *MatchService) now() time.Time {
+	if ms.AsOf != nil {
+		return *ms.AsOf
+	}
+	return time.Now()
+}
+
+// skills starts a skill query. When replaying, it covers the skills that existed at AsOf,
+// including ones deleted since. is_active and the skill text are only stored as they are now, and
+// text similarity uses the current term index, so those stay current even when replaying.
+func (ms *MatchService) skills() *gorm.DB {
+	if ms.AsOf == nil {
+		return config.DB
+	}
+	return config.DB.Unscoped().
+		Where("skills.created_at < ? AND (skills.deleted_at IS NULL OR skills.deleted_at > ?)", *ms.AsOf, *ms.AsOf)
+}
+
+// averageRating returns the user's average rating; ok is false if they have none. When replaying,
+// it's computed from the reviews written before AsOf instead of the current UserRating.
+func (ms *MatchService) averageRating(user models.User) (float64, bool) {
+	if ms.AsOf == nil {
+		if user.UserRating == nil {
+			return 0, false
+		}
+		return user.UserRating.AverageRating, true
+	}
+
+	var stats struct {
+		Average float64
+		Count   int64
+	}
+	config.DB.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("reviewee_id = ? AND created_at < ?", user.ID, *ms.AsOf).
+		Scan(&stats)
+	return stats.Average, stats.Count > 0
+}
+
 func (ms *MatchService) calculateLocationScore(location1, location2 string) int {
 	if location1 == "" || location2 == "" {
 		return 0
@@ -213,20 +444,21 @@ func (ms *MatchService) calculateLocationScore(location1, location2 string) int
 
 func (ms *MatchService) calculateActivityScore(userID uint) int {
 	// Check recent activity (last 30 days)
-	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
+	now := ms.now()
+	thirtyDaysAgo := now.AddDate(0, 0, -30)
 
 	var recentActivity int64
 
 	// Count recent skills posted
-	config.DB.Model(&models.Skill{}).Where("user_id = ? AND created_at > ?", userID, thirtyDaysAgo).Count(&recentActivity)
+	config.DB.Model(&models.Skill{}).Where("user_id = ? AND created_at > ? AND created_at < ?", userID, thirtyDaysAgo, now).Count(&recentActivity)
 
 	// Count recent exchanges
 	var recentExchanges int64
-	config.DB.Model(&models.Exchange{}).Where("requester_id = ? AND created_at > ?", userID, thirtyDaysAgo).Count(&recentExchanges)
+	config.DB.Model(&models.Exchange{}).Where("requester_id = ? AND created_at > ? AND created_at < ?", userID, thirtyDaysAgo, now).Count(&recentExchanges)
 
 	// Count recent messages
 	var recentMessages int64
-	config.DB.Model(&models.Message{}).Where("sender_id = ? AND created_at > ?", userID, thirtyDaysAgo).Count(&recentMessages)
+	config.DB.Model(&models.Message{}).Where("sender_id = ? AND created_at > ? AND created_at < ?", userID, thirtyDaysAgo, now).Count(&recentMessages)
 
 	totalActivity := int(recentActivity + recentExchanges + (recentMessages / 5)) // Weight messages less
 
@@ -248,7 +480,7 @@ func (ms *MatchService) calculateCompletionRate(userID uint) float64 {
 	// Count total exchanges where user was involved
 	config.DB.Model(&models.Exchange{}).
 		Joins("JOIN skills ON exchanges.skill_id = skills.id").
-		Where("exchanges.requester_id = ? OR skills.user_id = ?", userID, userID).
+		Where("(exchanges.requester_id = ? OR skills.user_id = ?) AND exchanges.created_at < ?", userID, userID, ms.now()).
 		Count(&totalExchanges)
 
 	if totalExchanges == 0 {
@@ -258,7 +490,7 @@ func (ms *MatchService) calculateCompletionRate(userID uint) float64 {
 	// Count completed exchanges
 	config.DB.Model(&models.Exchange{}).
 		Joins("JOIN skills ON exchanges.skill_id = skills.id").
-		Where("(exchanges.requester_id = ? OR skills.user_id = ?) AND exchanges.status = ?", userID, userID, "completed").
+		Where("(exchanges.requester_id = ? OR skills.user_id = ?) AND exchanges.status = ? AND exchanges.created_at < ?", userID, userID, "completed", ms.now()).
 		Count(&completedExchanges)
 
 	return float64(completedExchanges) / float64(totalExchanges)
@@ -269,6 +501,7 @@ func (ms *MatchService) estimateResponseTime(userID uint) string {
 	var avgHours float64
 
 	// Simplified calculation - in a real system, this would be more sophisticated
+	now := ms.now()
 	row := config.DB.Raw(`
 		SELECT AVG(EXTRACT(EPOCH FROM (m2.created_at - m1.created_at))/3600) as avg_hours
 		FROM messages m1
@@ -276,8 +509,8 @@ func (ms *MatchService) estimateResponseTime(userID uint) string {
 		WHERE m1.sender_id != ? AND m2.sender_id = ?
 		AND m2.created_at > m1.created_at
 		AND m2.created_at - m1.created_at < interval '24 hours'
-		AND m1.created_at > NOW() - interval '30 days'
-	`, userID, userID).Row()
+		AND m1.created_at > ? AND m2.created_at < ?
+	`, userID, userID, now.AddDate(0, 0, -30), now).Row()
 
 	row.Scan(&avgHours)
 
@@ -299,8 +532,8 @@ func (ms *MatchService) checkMutualInterest(userID1, userID2 uint) bool {
 	var user1Seeking []models.Skill
 	var user2Offering []models.Skill
 
-	config.DB.Where("user_id = ? AND skill_type = ? AND is_active = ?", userID1, "seeking", true).Find(&user1Seeking)
-	config.DB.Where("user_id = ? AND skill_type = ? AND is_active = ?", userID2, "offering", true).Find(&user2Offering)
+	ms.skills().Where("user_id = ? AND skill_type = ? AND is_active = ?", userID1, "seeking", true).Find(&user1Seeking)
+	ms.skills().Where("user_id = ? AND skill_type = ? AND is_active = ?", userID2, "offering", true).Find(&user2Offering)
 
 	for _, seeking := range user1Seeking {
 		for _, offering := range user2Offering {
@@ -313,51 +546,84 @@ func (ms *MatchService) checkMutualInterest(userID1, userID2 uint) bool {
 	return false
 }
 
-func (ms *MatchService) calculateMLRecommendationScore(currentUser models.User, offeredSkill models.Skill) int {
-	// Simplified ML-inspired scoring based on user behavior patterns
-	score := 0
+// loadSeekerSignals loads the current user's preference signals for calculateMLRecommendationScore
+func (ms *MatchService) loadSeekerSignals(userID uint) *seekerSignals {
+	signals := &seekerSignals{
+		exchangeCategories: make(map[string]int),
+		seekingLevels:      make(map[string]int),
+	}
 
 	// User's historical preferences
 	var userExchanges []models.Exchange
-	config.DB.Preload("Skill").Where("requester_id = ?", currentUser.ID).Find(&userExchanges)
-
-	// Category preference scoring
-	categoryPreferences := make(map[string]int)
+	config.DB.Preload("Skill").Where("requester_id = ? AND created_at < ?", userID, ms.now()).Find(&userExchanges)
 	for _, exchange := range userExchanges {
-		categoryPreferences[exchange.Skill.Category]++
+		signals.exchangeCategories[exchange.Skill.Category]++
 	}
 
-	if count, exists := categoryPreferences[offeredSkill.Category]; exists {
-		score += min(count*3, 15) // Max 15 points for category preference
+	var userSeeking []models.Skill
+	ms.skills().Where("user_id = ? AND skill_type = ?", userID, "seeking").Find(&userSeeking)
+	for _, skill := range userSeeking {
+		signals.seekingLevels[skill.Level]++
 	}
 
-	// Level progression pattern
-	seekingLevels := make(map[string]int)
-	var userSeeking []models.Skill
-	config.DB.Where("user_id = ? AND skill_type = ?", currentUser.ID, "seeking").Find(&userSeeking)
+	bookmarkService := &BookmarkService{}
+	signals.bookmarkedSkills = bookmarkService.BookmarkedSkillIDs(userID, ms.now())
+	signals.followedUsers = bookmarkService.FollowedUserIDs(userID, ms.now())
+	signals.bookmarkedCategories = bookmarkService.BookmarkedCategoryCounts(userID, ms.now())
 
-	for _, skill := range userSeeking {
-		seekingLevels[skill.Level]++
+	matchFeedbackService := &MatchFeedbackService{}
+	signals.categoryFeedback = matchFeedbackService.CategoryAdjustments(userID, ms.now())
+
+	return signals
+}
+
+func (ms *MatchService) calculateMLRecommendationScore(currentUser models.User, signals *seekerSignals, offeredSkill models.Skill) int {
+	// Use the trained ranking model when one is loaded
+	if score, ok := signals.modelScores[offeredSkill.ID]; ok && ms.scoring().UseRankingModel {
+		return score
+	}
+
+	// Simplified ML-inspired scoring based on user behavior patterns
+	score := 0
+
+	// Category preference scoring
+	if count, exists := signals.exchangeCategories[offeredSkill.Category]; exists {
+		score += min(count*3, 15) // Max 15 points for category preference
 	}
 
 	// Bonus for progressive skill building
-	if offeredSkill.Level == "intermediate" && seekingLevels["beginner"] > 0 {
+	if offeredSkill.Level == "intermediate" && signals.seekingLevels["beginner"] > 0 {
 		score += 5
 	}
-	if offeredSkill.Level == "advanced" && seekingLevels["intermediate"] > 0 {
+	if offeredSkill.Level == "advanced" && signals.seekingLevels["intermediate"] > 0 {
 		score += 5
 	}
 
+	// Likes and dismissals of earlier matches shift category preference
+	score += signals.categoryFeedback[offeredSkill.Category]
+
+	// Bookmarks and follows are explicit interest signals
+	if signals.bookmarkedSkills[offeredSkill.ID] {
+		score += 10
+	}
+	if signals.followedUsers[offeredSkill.UserID] {
+		score += 8
+	}
+	if count := signals.bookmarkedCategories[offeredSkill.Category]; count > 0 {
+		score += min(count*2, 10) // Max 10 points for bookmarked categories
+	}
+
 	return score
 }
 
-func (ms *MatchService) findAdvancedMutualMatches(currentUser models.User) ([]AdvancedMatch, error) {
-	var matches []AdvancedMatch
+// findAdvancedMutualCandidates finds skills offered by users who seek something the current user offers
+func (ms *MatchService) findAdvancedMutualCandidates(currentUser models.User) ([]matchCandidate, error) {
+	var candidates []matchCandidate
 
 	// Get user's offered skills
 	var userOfferedSkills []models.Skill
-	if err := config.DB.Where("user_id = ? AND skill_type = ? AND is_active = ?", currentUser.ID, "offering", true).Find(&userOfferedSkills).Error; err != nil {
-		return matches, err
+	if err := ms.skills().Where("user_id = ? AND skill_type = ? AND is_active = ?", currentUser.ID, "offering", true).Find(&userOfferedSkills).Error; err != nil {
+		return candidates, err
 	}
 
 	// Enhanced mutual matching logic
@@ -365,7 +631,7 @@ func (ms *MatchService) findAdvancedMutualMatches(currentUser models.User) ([]Ad
 		var seekingSkills []models.Skill
 		compatibleCategories := ms.getCategoryMatches(offeredSkill.Category)
 
-		if err := config.DB.Preload("User").Preload("User.UserRating").
+		if err := ms.skills().Preload("User").Preload("User.UserRating").
 			Where("skill_type = ? AND is_active = ? AND user_id != ? AND category IN ?",
 				"seeking", true, currentUser.ID, compatibleCategories).Find(&seekingSkills).Error; err != nil {
 			continue
@@ -374,13 +640,13 @@ func (ms *MatchService) findAdvancedMutualMatches(currentUser models.User) ([]Ad
 		for _, seekingSkill := range seekingSkills {
 			// Check for mutual offering
 			var mutualOffering []models.Skill
-			if err := config.DB.Where("user_id = ? AND skill_type = ? AND is_active = ?",
+			if err := ms.skills().Where("user_id = ? AND skill_type = ? AND is_active = ?",
 				seekingSkill.UserID, "offering", true).Find(&mutualOffering).Error; err != nil {
 				continue
 			}
 
 			var currentUserSeeking []models.Skill
-			if err := config.DB.Where("user_id = ? AND skill_type = ? AND is_active = ?",
+			if err := ms.skills().Where("user_id = ? AND skill_type = ? AND is_active = ?",
 				currentUser.ID, "seeking", true).Find(&currentUserSeeking).Error; err != nil {
 				continue
 			}
@@ -397,35 +663,49 @@ func (ms *MatchService) findAdvancedMutualMatches(currentUser models.User) ([]Ad
 					}
 
 					if categoryMatch {
-						advancedMatch := ms.calculateAdvancedMatchScore(currentUser, mySeeking, theirOffering)
-						advancedMatch.MatchScore += 35 // Higher bonus for mutual match
-						advancedMatch.MutualInterest = true
-						matches = append(matches, advancedMatch)
+						candidates = append(candidates, matchCandidate{seekingSkill: mySeeking, offeredSkill: theirOffering, mutual: true})
 					}
 				}
 			}
 		}
 	}
 
-	return matches, nil
+	return candidates, nil
 }
 
 func (ms *MatchService) removeDuplicateAdvancedMatches(matches []AdvancedMatch) []AdvancedMatch {
-	seen := make(map[string]AdvancedMatch)
+	seen := make(map[string]int)
 
+	// Keep first-seen order so ranking ties resolve the same way on every request
+	var result []AdvancedMatch
 	for _, match := range matches {
 		key := strconv.Itoa(int(match.UserID)) + "-" + strconv.Itoa(int(match.OfferedSkillID)) + "-" + strconv.Itoa(int(match.SeekingSkillID))
-		if existing, exists := seen[key]; !exists || match.MatchScore > existing.MatchScore {
-			seen[key] = match
+		if i, exists := seen[key]; !exists {
+			seen[key] = len(result)
+			result = append(result, match)
+		} else if match.MatchScore > result[i].MatchScore {
+			result[i] = match
 		}
 	}
 
-	var result []AdvancedMatch
-	for _, match := range seen {
-		result = append(result, match)
+	return result
+}
+
+func (ms *MatchService) applyFeedbackFilter(matches []AdvancedMatch, userID uint) []AdvancedMatch {
+	matchFeedbackService := &MatchFeedbackService{}
+	hidden := matchFeedbackService.HiddenSkillIDs(userID, ms.now())
+	if len(hidden) == 0 {
+		return matches
 	}
 
-	return result
+	var filtered []AdvancedMatch
+	for _, match := range matches {
+		if !hidden[match.OfferedSkillID] {
+			filtered = append(filtered, match)
+		}
+	}
+
+	return filtered
 }
 
 func (ms *MatchService) applyMLRanking(matches []AdvancedMatch, currentUser models.User) []AdvancedMatch {
@@ -449,7 +729,18 @@ func (ms *MatchService) applyMLRanking(matches []AdvancedMatch, currentUser mode
 		}
 
 		// Quaternary: Activity score
-		return a.ActivityScore > b.ActivityScore
+		if a.ActivityScore != b.ActivityScore {
+			return a.ActivityScore > b.ActivityScore
+		}
+
+		// Finally IDs, for a deterministic order
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		if a.OfferedSkillID != b.OfferedSkillID {
+			return a.OfferedSkillID < b.OfferedSkillID
+		}
+		return a.SeekingSkillID < b.SeekingSkillID
 	})
 
 	// Apply diversity filter to avoid showing too many matches from same user
@@ -461,7 +752,7 @@ func (ms *MatchService) applyDiversityFilter(matches []AdvancedMatch) []Advanced
 	var filtered []AdvancedMatch
 
 	for _, match := range matches {
-		if userCount[match.UserID] < 2 { // Max 2 matches per user
+		if userCount[match.UserID] < ms.scoring().MaxMatchesPerUser { // Max 2 matches per user by default
 			filtered = append(filtered, match)
 			userCount[match.UserID]++
 		}
@@ -498,73 +789,21 @@ func (ms *MatchService) calculateMatchScore(seekingSkill, offeredSkill models.Sk
 	}
 
 	// Title/description similarity score
-	score += ms.calculateTextSimilarity(seekingSkill.Title, offeredSkill.Title) * 20
-	score += ms.calculateTextSimilarity(seekingSkill.Description, offeredSkill.Description) * 10
+	score += ms.calculateTextSimilarity(seekingSkill.Title, offeredSkill.Title, 20)
+	score += ms.calculateTextSimilarity(seekingSkill.Description, offeredSkill.Description, 10)
 
 	// Tags similarity (if both have tags)
 	if seekingSkill.Tags != "" && offeredSkill.Tags != "" {
-		score += ms.calculateTagSimilarity(seekingSkill.Tags, offeredSkill.Tags) * 15
+		score += ms.calculateTextSimilarity(seekingSkill.Tags, offeredSkill.Tags, 15)
 	}
 
 	return score
 }
 
-func (ms *MatchService) calculateTextSimilarity(text1, text2 string) int {
-	if text1 == "" || text2 == "" {
-		return 0
-	}
-
-	text1 = strings.ToLower(text1)
-	text2 = strings.ToLower(text2)
-
-	words1 := strings.Fields(text1)
-	words2 := strings.Fields(text2)
-
-	commonWords := 0
-	for _, word1 := range words1 {
-		for _, word2 := range words2 {
-			if word1 == word2 && len(word1) > 2 { // Only count words longer than 2 characters
-				commonWords++
-				break
-			}
-		}
-	}
-
-	if len(words1) == 0 || len(words2) == 0 {
-		return 0
-	}
-
-	return (commonWords * 100) / max(len(words1), len(words2))
-}
-
-func (ms *MatchService) calculateTagSimilarity(tags1, tags2 string) int {
-	// Assuming tags are comma-separated
-	tagList1 := strings.Split(strings.ToLower(tags1), ",")
-	tagList2 := strings.Split(strings.ToLower(tags2), ",")
-
-	// Trim whitespace
-	for i := range tagList1 {
-		tagList1[i] = strings.TrimSpace(tagList1[i])
-	}
-	for i := range tagList2 {
-		tagList2[i] = strings.TrimSpace(tagList2[i])
-	}
-
-	commonTags := 0
-	for _, tag1 := range tagList1 {
-		for _, tag2 := range tagList2 {
-			if tag1 == tag2 && tag1 != "" {
-				commonTags++
-				break
-			}
-		}
-	}
-
-	if len(tagList1) == 0 || len(tagList2) == 0 {
-		return 0
-	}
-
-	return (commonTags * 100) / max(len(tagList1), len(tagList2))
+// calculateTextSimilarity scores two texts from 0 to maxPoints by TF-IDF cosine similarity,
+// so "JS" matches "JavaScript" and "cooking" matches "cook"
+func (ms *MatchService) calculateTextSimilarity(text1, text2 string, maxPoints int) int {
+	return int(math.Round(TextSimilarity(text1, text2) * float64(maxPoints)))
 }
 
 func (ms *MatchService) findMutualMatches(userID uint) ([]models.Match, error) {
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"fmt"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The Notify methods publish an event's notifications as part of tx, so that they go out if and
+// only if the change they announce is committed. Pass config.DB when there is no transaction.
+
+// Longest message preview shown in a new message notification or a reply's quote
+const messagePreviewLength = 100
+
+// messagePreview shortens a message to a one-line preview
+func messagePreview(message models.Message) string {
+	preview := message.Content
+	if message.MessageType == "image" || message.MessageType == "file" {
+		preview = "Sent a " + message.MessageType
+	}
+	if runes := []rune(preview); len(runes) > messagePreviewLength {
+		preview = string(runes[:messagePreviewLength]) + "…"
+	}
+	return preview
+}
+
+// NotifyExchangeRequested tells the skill owner about a new exchange request
+func (ns *NotificationService) NotifyExchangeRequested(tx *gorm.DB, exchange models.Exchange) error {
+	if err := tx.Preload("Requester").Preload("Skill.User").First(&exchange, exchange.ID).Error; err != nil {
+		return err
+	}
+
+	template, err := RenderEmail(EmailExchangeRequest, exchange.Skill.User.Locale,
+		exchangeRequestEmailData(exchange.Skill.User, exchange.Requester, exchange.Skill, exchange))
+	if err != nil {
+		return err
+	}
+
+	return Notifications().Publish(tx, NotificationMessage{
+		Event:    EventExchangeRequested,
+		UserID:   exchange.Skill.UserID,
+		Email:    exchange.Skill.User.Email,
+		Locale:   exchange.Skill.User.Locale,
+		Subject:  template.Subject,
+		Body:     template.Body,
+		HTMLBody: template.HTMLBody,
+		Summary:  fmt.Sprintf("%s requested an exchange for %s", exchange.Requester.FullName, exchange.Skill.Title),
+		Link:     fmt.Sprintf("/exchanges/%d", exchange.ID),
+		Data:     map[string]interface{}{"exchange_id": exchange.ID, "skill_id": exchange.SkillID, "requester_id": exchange.RequesterID},
+	})
+}
+
+// NotifyExchangeStatusChanged tells the requester their exchange was accepted, rejected, completed or cancelled
+func (ns *NotificationService) NotifyExchangeStatusChanged(tx *gorm.DB, exchange models.Exchange) error {
+	if err := tx.Preload("Requester").Preload("Skill.User").First(&exchange, exchange.ID).Error; err != nil {
+		return err
+	}
+
+	template, err := RenderEmail(EmailExchangeStatus, exchange.Requester.Locale,
+		exchangeStatusEmailData(exchange.Requester, exchange.Skill.User, exchange.Skill, exchange))
+	if err != nil {
+		return err
+	}
+
+	return Notifications().Publish(tx, NotificationMessage{
+		Event:    EventExchangeStatusChanged,
+		UserID:   exchange.RequesterID,
+		Email:    exchange.Requester.Email,
+		Locale:   exchange.Requester.Locale,
+		Subject:  template.Subject,
+		Body:     template.Body,
+		HTMLBody: template.HTMLBody,
+		Summary:  fmt.Sprintf("Your exchange for %s is now %s", exchange.Skill.Title, exchange.Status),
+		Link:     fmt.Sprintf("/exchanges/%d", exchange.ID),
+		Data:     map[string]interface{}{"exchange_id": exchange.ID, "skill_id": exchange.SkillID, "status": exchange.Status},
+	})
+}
+
+// NotifyReviewCreated tells the reviewee about a new review
+func (ns *NotificationService) NotifyReviewCreated(tx *gorm.DB, review models.Review) error {
+	if err := tx.Preload("Reviewer").Preload("Reviewee").First(&review, review.ID).Error; err != nil {
+		return err
+	}
+
+	template, err := RenderEmail(EmailNewReview, review.Reviewee.Locale, newReviewEmailData(review.Reviewee, review.Reviewer, review))
+	if err != nil {
+		return err
+	}
+
+	return Notifications().Publish(tx, NotificationMessage{
+		Event:    EventReviewCreated,
+		UserID:   review.RevieweeID,
+		Email:    review.Reviewee.Email,
+		Locale:   review.Reviewee.Locale,
+		Subject:  template.Subject,
+		Body:     template.Body,
+		HTMLBody: template.HTMLBody,
+		Summary:  fmt.Sprintf("%s left you a %d-star review", review.Reviewer.FullName, review.Rating),
+		Link:     "/profile",
+		Data:     map[string]interface{}{"review_id": review.ID, "exchange_id": review.ExchangeID, "rating": review.Rating},
+	})
+}
+
+// NotifyMessageCreated tells the other members of a chat room about a new message
+func (ns *NotificationService) NotifyMessageCreated(tx *gorm.DB, message models.Message, room models.ChatRoom) error {
+	var sender models.User
+	if err := tx.First(&sender, message.SenderID).Error; err != nil {
+		return err
+	}
+
+	var recipients []models.User
+	if err := tx.Where("id IN (SELECT user_id FROM chat_room_members WHERE chat_room_id = ?) AND id <> ?", room.ID, message.SenderID).
+		Find(&recipients).Error; err != nil {
+		return err
+	}
+
+	preview := messagePreview(message)
+
+	for _, recipient := range recipients {
+		template, err := RenderEmail(EmailNewMessage, recipient.Locale, newMessageEmailData(recipient, sender, room, preview))
+		if err != nil {
+			return err
+		}
+
+		if err := Notifications().Publish(tx, NotificationMessage{
+			Event:    EventMessageCreated,
+			UserID:   recipient.ID,
+			Email:    recipient.Email,
+			Locale:   recipient.Locale,
+			Subject:  template.Subject,
+			Body:     template.Body,
+			HTMLBody: template.HTMLBody,
+			Summary:  fmt.Sprintf("%s: %s", sender.FullName, preview),
+			Link:     fmt.Sprintf("/chat/%d", room.ID),
+			Data:     map[string]interface{}{"chat_room_id": room.ID, "message_id": message.ID, "sender_id": message.SenderID},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyChatInvited tells users they were added to a group chat
+func (ns *NotificationService) NotifyChatInvited(tx *gorm.DB, room models.ChatRoom, inviterID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	var inviter models.User
+	if err := tx.First(&inviter, inviterID).Error; err != nil {
+		return err
+	}
+
+	var invitees []models.User
+	if err := tx.Where("id IN ?", userIDs).Find(&invitees).Error; err != nil {
+		return err
+	}
+
+	for _, invitee := range invitees {
+		template, err := RenderEmail(EmailChatInvited, invitee.Locale, chatInvitedEmailData(invitee, inviter, room))
+		if err != nil {
+			return err
+		}
+
+		if err := Notifications().Publish(tx, NotificationMessage{
+			Event:    EventChatInvited,
+			UserID:   invitee.ID,
+			Email:    invitee.Email,
+			Locale:   invitee.Locale,
+			Subject:  template.Subject,
+			Body:     template.Body,
+			HTMLBody: template.HTMLBody,
+			Summary:  fmt.Sprintf("%s added you to the group chat %s", inviter.FullName, room.Name),
+			Link:     fmt.Sprintf("/chat/%d", room.ID),
+			Data:     map[string]interface{}{"chat_room_id": room.ID, "inviter_id": inviterID},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyMatchesFound tells a user about new skill matches
+func (ns *NotificationService) NotifyMatchesFound(tx *gorm.DB, userID uint, matches []models.Match) error {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var user models.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	skillIDs := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		skillIDs = append(skillIDs, match.OfferedSkillID)
+	}
+
+	summary := fmt.Sprintf("%s offers %s", matches[0].UserName, matches[0].OfferedSkill)
+	if len(matches) > 1 {
+		summary = fmt.Sprintf("%s and %d more", summary, len(matches)-1)
+	}
+
+	template, err := RenderEmail(EmailNewMatch, user.Locale, newMatchEmailData(user, matches))
+	if err != nil {
+		return err
+	}
+
+	return Notifications().Publish(tx, NotificationMessage{
+		Event:    EventMatchNew,
+		UserID:   userID,
+		Email:    user.Email,
+		Locale:   user.Locale,
+		Subject:  template.Subject,
+		Body:     template.Body,
+		HTMLBody: template.HTMLBody,
+		Summary:  summary,
+		Link:     "/matches",
+		Data:     map[string]interface{}{"skill_ids": skillIDs},
+	})
+}
+
+// NotifyAccountLocked warns a user that their account was locked after repeated failed logins
+func (ns *NotificationService) NotifyAccountLocked(tx *gorm.DB, user models.User, ipAddress string, lockedUntil time.Time) error {
+	template, err := RenderEmail(EmailAccountLocked, user.Locale, accountLockedEmailData(user, ipAddress, lockedUntil))
+	if err != nil {
+		return err
+	}
+
+	return Notifications().Publish(tx, NotificationMessage{
+		Event:    EventAccountLocked,
+		UserID:   user.ID,
+		Email:    user.Email,
+		Locale:   user.Locale,
+		Subject:  template.Subject,
+		Body:     template.Body,
+		HTMLBody: template.HTMLBody,
+		Summary:  fmt.Sprintf("Locked until %s after failed logins from %s", lockedUntil.Format("Jan 2, 2006 15:04 MST"), ipAddress),
+		Data:     map[string]interface{}{"ip_address": ipAddress, "locked_until": lockedUntil},
+	})
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Most matches a user is told about per run
+const maxNewMatchesPerNotification = 5
+
+// SendWeeklyDigests emails every user their weekly activity summary, at most once per ISO week
+func (ns *NotificationService) SendWeeklyDigests() error {
+	year, week := time.Now().ISOWeek()
+	key := fmt.Sprintf("%d-W%02d", year, week)
+
+	var userIDs []uint
+	if err := config.DB.Model(&models.User{}).Order("id").Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	emailService := &EmailService{}
+	sent, failed := 0, 0
+	for _, userID := range userIDs {
+		// The claim and the outbox message commit together, so a failure leaves the digest for the next run
+		claimed := false
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if claimed, err = ns.Claim(tx, userID, "weekly_digest", key); err != nil || !claimed {
+				return err
+			}
+			return emailService.SendWeeklyDigestNotification(tx, userID)
+		})
+		if err != nil {
+			log.Printf("Failed to send weekly digest to user %d: %v", userID, err)
+			failed++
+			continue
+		}
+		if claimed {
+			sent++
+		}
+	}
+
+	log.Printf("Sent %d weekly digests for %s", sent, key)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d weekly digests failed", failed, sent+failed)
+	}
+	return nil
+}
+
+// NotifyNewMatches tells users about matches scoring at least minScore that they haven't been told
+// about before. Each offered skill is announced to a user once, however its score changes later.
+func (ns *NotificationService) NotifyNewMatches(minScore int) error {
+	var userIDs []uint
+	if err := config.DB.Model(&models.Skill{}).
+		Where("skill_type = ? AND is_active = ?", "seeking", true).
+		Distinct().Order("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+
+	matchService := &MatchService{}
+	notified := 0
+	for _, userID := range userIDs {
+		matches, err := matchService.FindAdvancedMatches(userID)
+		if err != nil {
+			log.Printf("Failed to find matches for user %d: %v", userID, err)
+			continue
+		}
+
+		found := false
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			newMatches, err := ns.claimNewMatches(tx, userID, matches, minScore)
+			if err != nil {
+				return err
+			}
+			found = len(newMatches) > 0
+			return ns.NotifyMatchesFound(tx, userID, newMatches)
+		})
+		if err != nil {
+			return err
+		}
+		if found {
+			notified++
+		}
+	}
+
+	log.Printf("Notified %d users about new matches", notified)
+	return nil
+}
+
+// claimNewMatches returns the best matches above minScore whose skills the user hasn't been told about
+func (ns *NotificationService) claimNewMatches(tx *gorm.DB, userID uint, matches []AdvancedMatch, minScore int) ([]models.Match, error) {
+	var newMatches []models.Match
+	seen := make(map[uint]bool)
+
+	// Matches are already ranked best first
+	for _, match := range matches {
+		if len(newMatches) == maxNewMatchesPerNotification {
+			break
+		}
+		if match.MatchScore < minScore || seen[match.OfferedSkillID] {
+			continue
+		}
+		seen[match.OfferedSkillID] = true
+
+		claimed, err := ns.Claim(tx, userID, "new_match", fmt.Sprintf("skill:%d", match.OfferedSkillID))
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			newMatches = append(newMatches, match.Match)
+		}
+	}
+
+	return newMatches, nil
+}
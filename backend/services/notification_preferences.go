@@ -0,0 +1,450 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Delivery modes
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryDigest    = "digest"
+	DeliveryOff       = "off"
+)
+
+// Queued notifications older than this are dropped instead of retried
+const queuedNotificationMaxAge = 7 * 24 * time.Hour
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe link")
+
+// NotificationPreferenceChannels lists the events users can configure and the channels for each
+var NotificationPreferenceChannels = map[string][]string{
+	EventExchangeRequested:     {ChannelEmail, ChannelInApp},
+	EventExchangeStatusChanged: {ChannelEmail, ChannelInApp},
+	EventReviewCreated:         {ChannelEmail, ChannelInApp},
+	EventMessageCreated:        {ChannelInApp},
+	EventChatInvited:           {ChannelInApp},
+	EventMatchNew:              {ChannelEmail, ChannelInApp},
+	EventSavedSearchMatched:    {ChannelEmail, ChannelInApp},
+	EventWeeklyDigest:          {ChannelEmail},
+}
+
+// Events users can't turn off; they are also sent during quiet hours
+var mandatoryNotificationEvents = map[string]bool{
+	EventAccountLocked: true,
+}
+
+type NotificationPreferenceService struct{}
+
+// Apply implements NotificationPolicy. Immediate email gets an unsubscribe link and waits out the
+// recipient's quiet hours; digested email is queued for the daily digest. Other channels (and
+// messages without a recipient) are only subject to "off".
+func (nps *NotificationPreferenceService) Apply(message NotificationMessage, channel string) (NotificationMessage, bool, error) {
+	if message.UserID == 0 || mandatoryNotificationEvents[message.Event] {
+		return message, true, nil
+	}
+	if channel != ChannelEmail && channel != ChannelInApp {
+		return message, true, nil
+	}
+
+	switch nps.Mode(message.UserID, message.Event, channel) {
+	case DeliveryOff:
+		return message, false, nil
+	case DeliveryDigest:
+		return message, false, nps.queue(message, channel, DeliveryDigest, nil)
+	}
+
+	if channel == ChannelEmail {
+		message.UnsubscribeURL = UnsubscribeURL(message.UserID, message.Event)
+
+		if until := QuietHoursEnd(nps.Settings(message.UserID), time.Now()); !until.IsZero() {
+			return message, false, nps.queue(message, channel, "deferred", &until)
+		}
+	}
+
+	return message, true, nil
+}
+
+// Mode returns how a user receives an event on a channel: their preference for the event, else
+// their preference for all events ("*"), else immediate
+func (nps *NotificationPreferenceService) Mode(userID uint, event, channel string) string {
+	var preferences []models.NotificationPreference
+	config.DB.Where("user_id = ? AND channel = ? AND event IN ?", userID, channel, []string{event, "*"}).Find(&preferences)
+
+	mode := DeliveryImmediate
+	for _, preference := range preferences {
+		if preference.Event == event {
+			return preference.Mode
+		}
+		mode = preference.Mode
+	}
+	return mode
+}
+
+// Preferences returns the user's mode for every configurable event and channel
+func (nps *NotificationPreferenceService) Preferences(userID uint) []models.NotificationPreference {
+	var stored []models.NotificationPreference
+	config.DB.Where("user_id = ?", userID).Find(&stored)
+
+	modes := make(map[string]string)
+	for _, preference := range stored {
+		modes[preference.Event+"|"+preference.Channel] = preference.Mode
+	}
+
+	var preferences []models.NotificationPreference
+	for _, event := range sortedNotificationEvents() {
+		for _, channel := range NotificationPreferenceChannels[event] {
+			mode, ok := modes[event+"|"+channel]
+			if !ok {
+				mode, ok = modes["*|"+channel]
+			}
+			if !ok {
+				mode = DeliveryImmediate
+			}
+			preferences = append(preferences, models.NotificationPreference{Event: event, Channel: channel, Mode: mode})
+		}
+	}
+	return preferences
+}
+
+// ValidatePreference checks that a mode can be set for an event ("*" for all events) and channel
+func (nps *NotificationPreferenceService) ValidatePreference(event, channel, mode string) error {
+	if mode != DeliveryImmediate && mode != DeliveryDigest && mode != DeliveryOff {
+		return fmt.Errorf("mode must be immediate, digest or off")
+	}
+
+	if event == "*" {
+		if channel != ChannelEmail && channel != ChannelInApp {
+			return fmt.Errorf("unknown channel %q", channel)
+		}
+	} else {
+		channels, ok := NotificationPreferenceChannels[event]
+		if !ok {
+			return fmt.Errorf("unknown event %q", event)
+		}
+		if !containsString(channels, channel) {
+			return fmt.Errorf("%s is not sent on %s", event, channel)
+		}
+	}
+
+	if mode == DeliveryDigest && (channel != ChannelEmail || event == EventWeeklyDigest) {
+		return fmt.Errorf("digest is only available for email notifications")
+	}
+	return nil
+}
+
+// SetPreference stores a user's mode for an event and channel
+func (nps *NotificationPreferenceService) SetPreference(userID uint, event, channel, mode string) error {
+	return setPreference(config.DB, userID, event, channel, mode)
+}
+
+func setPreference(db *gorm.DB, userID uint, event, channel, mode string) error {
+	preference := models.NotificationPreference{UserID: userID, Event: event, Channel: channel, Mode: mode}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mode", "updated_at"}),
+	}).Create(&preference).Error
+}
+
+// Settings returns a user's timezone and quiet hours, defaulting to UTC without quiet hours
+func (nps *NotificationPreferenceService) Settings(userID uint) models.NotificationSettings {
+	settings := models.NotificationSettings{UserID: userID, Timezone: "UTC"}
+	config.DB.Where("user_id = ?", userID).First(&settings)
+	return settings
+}
+
+// UpdateSettings stores a user's timezone and quiet hours
+func (nps *NotificationPreferenceService) UpdateSettings(settings models.NotificationSettings) error {
+	settings.ID = 0 // Upsert on user_id, whether or not the settings were loaded
+	return config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"timezone", "quiet_hours_start", "quiet_hours_end", "updated_at"}),
+	}).Create(&settings).Error
+}
+
+// Unsubscribe turns off the email named by a signed unsubscribe token. A token for all events ("*")
+// also turns off the per-event email preferences, which would otherwise take priority.
+func (nps *NotificationPreferenceService) Unsubscribe(token string) (uint, string, error) {
+	userID, event, err := ParseUnsubscribeToken(token)
+	if err != nil {
+		return 0, "", err
+	}
+	if event != "*" {
+		return userID, event, nps.SetPreference(userID, event, ChannelEmail, DeliveryOff)
+	}
+
+	return userID, event, config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := setPreference(tx, userID, "*", ChannelEmail, DeliveryOff); err != nil {
+			return err
+		}
+		return tx.Model(&models.NotificationPreference{}).
+			Where("user_id = ? AND channel = ? AND event <> ?", userID, ChannelEmail, "*").
+			Update("mode", DeliveryOff).Error
+	})
+}
+
+// FlushQueued sends deferred notifications whose quiet hours are over and the daily digests that are due
+func (nps *NotificationPreferenceService) FlushQueued() error {
+	now := time.Now()
+
+	// Drop anything that kept failing
+	config.DB.Where("created_at < ?", now.Add(-queuedNotificationMaxAge)).Delete(&models.QueuedNotification{})
+
+	var deferred []models.QueuedNotification
+	if err := config.DB.Where("kind = ? AND deliver_after <= ?", "deferred", now).Order("id").Find(&deferred).Error; err != nil {
+		return err
+	}
+	for _, queued := range deferred {
+		if err := nps.sendQueued(queued); err != nil {
+			log.Printf("Failed to send deferred notification %d: %v", queued.ID, err)
+			continue
+		}
+		config.DB.Delete(&queued)
+	}
+
+	var userIDs []uint
+	if err := config.DB.Model(&models.QueuedNotification{}).Where("kind = ?", DeliveryDigest).
+		Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	failed := 0
+	for _, userID := range userIDs {
+		if err := nps.sendDigestIfDue(userID, now); err != nil {
+			log.Printf("Failed to send daily digest to user %d: %v", userID, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d daily digests failed", failed, len(userIDs))
+	}
+	return nil
+}
+
+func (nps *NotificationPreferenceService) sendQueued(queued models.QueuedNotification) error {
+	var message NotificationMessage
+	if err := json.Unmarshal([]byte(queued.Payload), &message); err != nil {
+		return err
+	}
+
+	// The preference may have been turned off while the message waited
+	if nps.Mode(queued.UserID, queued.Event, queued.Channel) == DeliveryOff {
+		return nil
+	}
+
+	channel := Notifications().Channel(queued.Channel)
+	if channel == nil {
+		return fmt.Errorf("channel %s not configured", queued.Channel)
+	}
+	return channel.Notify(message)
+}
+
+// sendDigestIfDue sends a user's queued notifications as one email once a day, at the digest hour
+// in their timezone and outside their quiet hours
+func (nps *NotificationPreferenceService) sendDigestIfDue(userID uint, now time.Time) error {
+	settings := nps.Settings(userID)
+	local := now.In(userLocation(settings))
+	if local.Hour() < config.AppConfig.NotifyDigestHour || !QuietHoursEnd(settings, now).IsZero() {
+		return nil
+	}
+
+	notificationService := &NotificationService{}
+	day := local.Format("2006-01-02")
+	claimed, err := notificationService.Claim(config.DB, userID, "daily_digest", day)
+	if err != nil || !claimed {
+		return err
+	}
+
+	if err := nps.sendDigest(userID); err != nil {
+		notificationService.Unclaim(userID, "daily_digest", day)
+		return err
+	}
+	return nil
+}
+
+func (nps *NotificationPreferenceService) sendDigest(userID uint) error {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The account was deleted; nothing will be sent to it
+			return config.DB.Where("user_id = ?", userID).Delete(&models.QueuedNotification{}).Error
+		}
+		return err
+	}
+
+	var queued []models.QueuedNotification
+	if err := config.DB.Where("user_id = ? AND kind = ?", userID, DeliveryDigest).Order("created_at").Find(&queued).Error; err != nil {
+		return err
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+
+	items := make([]DigestItem, 0, len(queued))
+	ids := make([]uint, 0, len(queued))
+	for _, item := range queued {
+		ids = append(ids, item.ID)
+
+		var message NotificationMessage
+		if err := json.Unmarshal([]byte(item.Payload), &message); err != nil {
+			continue
+		}
+		items = append(items, DigestItem{Subject: message.Subject, Summary: message.Summary, Link: message.Link})
+	}
+
+	template, err := RenderEmail(EmailDailyDigest, user.Locale, dailyDigestEmailData(user, items))
+	if err != nil {
+		return err
+	}
+
+	channel := Notifications().Channel(ChannelEmail)
+	if channel == nil {
+		return errors.New("email channel not configured")
+	}
+	if err := channel.Notify(NotificationMessage{
+		Event:          EventDailyDigest,
+		UserID:         userID,
+		Email:          user.Email,
+		Locale:         user.Locale,
+		Subject:        template.Subject,
+		Body:           template.Body,
+		HTMLBody:       template.HTMLBody,
+		UnsubscribeURL: UnsubscribeURL(userID, "*"),
+	}); err != nil {
+		return err
+	}
+
+	return config.DB.Delete(&models.QueuedNotification{}, ids).Error
+}
+
+func (nps *NotificationPreferenceService) queue(message NotificationMessage, channel, kind string, deliverAfter *time.Time) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return config.DB.Create(&models.QueuedNotification{
+		UserID:       message.UserID,
+		Event:        message.Event,
+		Channel:      channel,
+		Kind:         kind,
+		Payload:      string(payload),
+		DeliverAfter: deliverAfter,
+	}).Error
+}
+
+// QuietHoursEnd returns when the quiet hours around t end, or the zero time if t is outside them.
+// Quiet hours may wrap past midnight (e.g. 22:00-07:00).
+func QuietHoursEnd(settings models.NotificationSettings, t time.Time) time.Time {
+	start, errStart := parseClock(settings.QuietHoursStart)
+	end, errEnd := parseClock(settings.QuietHoursEnd)
+	if errStart != nil || errEnd != nil || start == end {
+		return time.Time{}
+	}
+
+	local := t.In(userLocation(settings))
+	now := local.Hour()*60 + local.Minute()
+
+	inside := now >= start && now < end
+	if start > end {
+		inside = now >= start || now < end
+	}
+	if !inside {
+		return time.Time{}
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, local.Location())
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// ValidateClock checks an HH:MM time of day
+func ValidateClock(value string) error {
+	_, err := parseClock(value)
+	return err
+}
+
+// parseClock returns minutes since midnight for HH:MM
+func parseClock(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	hour, errHour := strconv.Atoi(parts[0])
+	minute, errMinute := strconv.Atoi(parts[1])
+	if errHour != nil || errMinute != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return hour*60 + minute, nil
+}
+
+func userLocation(settings models.NotificationSettings) *time.Location {
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+func sortedNotificationEvents() []string {
+	events := make([]string, 0, len(NotificationPreferenceChannels))
+	for event := range NotificationPreferenceChannels {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	return events
+}
+
+// UnsubscribeURL returns the signed link that turns off an event's email ("*" for all email)
+func UnsubscribeURL(userID uint, event string) string {
+	return strings.TrimRight(config.AppConfig.PublicAPIURL, "/") + "/api/notifications/unsubscribe?token=" + UnsubscribeToken(userID, event)
+}
+
+// UnsubscribeToken signs a user and event. It doesn't expire, so old emails keep working.
+func UnsubscribeToken(userID uint, event string) string {
+	payload := fmt.Sprintf("%d:%s", userID, event)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(unsubscribeMAC(payload))
+}
+
+// ParseUnsubscribeToken verifies a token and returns the user and event it names
+func ParseUnsubscribeToken(token string) (uint, string, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	payload, err1 := base64.RawURLEncoding.DecodeString(encodedPayload)
+	mac, err2 := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err1 != nil || err2 != nil || !hmac.Equal(mac, unsubscribeMAC(string(payload))) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+
+	userPart, event, ok := strings.Cut(string(payload), ":")
+	userID, err := strconv.ParseUint(userPart, 10, 32)
+	if !ok || err != nil || event == "" {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	return uint(userID), event, nil
+}
+
+func unsubscribeMAC(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	mac.Write([]byte("unsubscribe:" + payload))
+	return mac.Sum(nil)
+}
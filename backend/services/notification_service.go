@@ -0,0 +1,113 @@
+package services
+
+import (
+	"encoding/json"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationService struct{}
+
+// Create stores an in-app notification for a user. data is marshalled to JSON when not nil.
+func (ns *NotificationService) Create(userID uint, notificationType, title, body, link string, data interface{}) (*models.Notification, error) {
+	notification := models.Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+		Link:   link,
+	}
+
+	if data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		notification.Data = string(payload)
+	}
+
+	if err := config.DB.Create(&notification).Error; err != nil {
+		return nil, err
+	}
+
+	Push().Publish(userID, "notification.created", map[string]interface{}{
+		"notification": notification,
+		"unread_count": ns.UnreadCount(userID),
+	})
+
+	return &notification, nil
+}
+
+// UnreadCount returns how many of a user's notifications are unread
+func (ns *NotificationService) UnreadCount(userID uint) int64 {
+	var count int64
+	config.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count)
+	return count
+}
+
+// MarkRead marks one of a user's notifications as read. It returns gorm.ErrRecordNotFound if the
+// notification doesn't exist or belongs to someone else.
+func (ns *NotificationService) MarkRead(userID, notificationID uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := config.DB.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		return nil, err
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := config.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			return nil, err
+		}
+		notification.ReadAt = &now
+
+		ns.publishRead(userID, []uint{notification.ID})
+	}
+
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of a user as read and returns how many there were
+func (ns *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	result := config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		ns.publishRead(userID, nil)
+	}
+	return result.RowsAffected, nil
+}
+
+// publishRead lets the user's other open sessions update their unread badge; nil IDs means all
+func (ns *NotificationService) publishRead(userID uint, notificationIDs []uint) {
+	Push().Publish(userID, "notification.read", map[string]interface{}{
+		"notification_ids": notificationIDs,
+		"all":              notificationIDs == nil,
+		"unread_count":     ns.UnreadCount(userID),
+	})
+}
+
+// Claim records that a notification identified by kind and key is being sent to a user. It returns
+// false if it was already claimed, so concurrent or repeated runs never send the same one twice. Claim
+// in the same transaction that publishes the notification, so a rollback releases the claim.
+func (ns *NotificationService) Claim(tx *gorm.DB, userID uint, kind, key string) (bool, error) {
+	sent := models.SentNotification{UserID: userID, Kind: kind, Key: key}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sent)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Unclaim releases a claim whose notification failed to send, so a later run can retry it
+func (ns *NotificationService) Unclaim(userID uint, kind, key string) error {
+	return config.DB.Where("user_id = ? AND kind = ? AND key = ?", userID, kind, key).
+		Delete(&models.SentNotification{}).Error
+}
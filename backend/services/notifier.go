@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"skillswap-backend/config"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Notification events
+const (
+	EventExchangeRequested     = "exchange.requested"
+	EventExchangeStatusChanged = "exchange.status_changed"
+	EventReviewCreated         = "review.created"
+	EventMessageCreated        = "message.created"
+	EventChatInvited           = "chat.invited"
+	EventMatchNew              = "match.new"
+	EventAccountLocked         = "account.locked"
+	EventSavedSearchMatched    = "saved_search.new_skills"
+	EventWeeklyDigest          = "digest.weekly"
+	EventDailyDigest           = "digest.daily"
+)
+
+// Notification channels
+const (
+	ChannelEmail   = "email"
+	ChannelInApp   = "in_app"
+	ChannelWebhook = "webhook"
+)
+
+// DefaultNotificationRoutes lists the channels each event is delivered on
+var DefaultNotificationRoutes = map[string][]string{
+	EventExchangeRequested:     {ChannelEmail, ChannelInApp, ChannelWebhook},
+	EventExchangeStatusChanged: {ChannelEmail, ChannelInApp, ChannelWebhook},
+	EventReviewCreated:         {ChannelEmail, ChannelInApp, ChannelWebhook},
+	EventMessageCreated:        {ChannelInApp, ChannelWebhook},
+	EventChatInvited:           {ChannelInApp, ChannelWebhook},
+	EventMatchNew:              {ChannelEmail, ChannelInApp, ChannelWebhook},
+	EventAccountLocked:         {ChannelEmail},
+}
+
+// NotificationMessage is an event rendered for one recipient. Each channel uses the fields it needs:
+// email sends Subject, Body and HTMLBody to Email, in-app stores Subject, Summary, Link and Data.
+type NotificationMessage struct {
+	ID             string                 `json:"id,omitempty"` // Identifies the event to webhook receivers; set when published
+	Event          string                 `json:"event"`
+	UserID         uint                   `json:"user_id"`
+	Email          string                 `json:"email,omitempty"`
+	Locale         string                 `json:"locale,omitempty"` // The recipient's language, for text added by channels such as unsubscribe footers
+	Subject        string                 `json:"subject"`
+	Body           string                 `json:"body,omitempty"`      // Full plain text, for email
+	HTMLBody       string                 `json:"html_body,omitempty"` // HTML alternative of Body
+	Summary        string                 `json:"summary"`             // One line, for in-app notifications
+	Link           string                 `json:"link,omitempty"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	UnsubscribeURL string                 `json:"unsubscribe_url,omitempty"` // Set on email the recipient can opt out of
+	WebhookID      uint                   `json:"webhook_id,omitempty"`      // The subscription a webhook message is for
+}
+
+// Notifier delivers notifications over one channel
+type Notifier interface {
+	Notify(message NotificationMessage) error
+}
+
+// NotificationPolicy decides, per recipient, whether a message goes out on a channel now. It may
+// adjust the message, or hold it back (turned off, digested, or deferred past quiet hours).
+type NotificationPolicy interface {
+	Apply(message NotificationMessage, channel string) (NotificationMessage, bool, error)
+}
+
+// NotificationRouter delivers each event on the channels its route lists. Messages published with
+// Publish go through the outbox, so they are only sent if the caller's transaction commits.
+type NotificationRouter struct {
+	channels map[string]Notifier
+	routes   map[string][]string
+	policy   NotificationPolicy
+}
+
+// NewNotificationRouter creates a router; routes naming a channel that isn't registered skip it
+func NewNotificationRouter(routes map[string][]string, channels map[string]Notifier) *NotificationRouter {
+	return &NotificationRouter{channels: channels, routes: routes}
+}
+
+// WithPolicy sets the policy applied to every message before delivery
+func (nr *NotificationRouter) WithPolicy(policy NotificationPolicy) *NotificationRouter {
+	nr.policy = policy
+	return nr
+}
+
+// Channel returns the notifier registered for a channel, or nil
+func (nr *NotificationRouter) Channel(name string) Notifier {
+	return nr.channels[name]
+}
+
+// Dispatch delivers a message on every channel routed for its event
+func (nr *NotificationRouter) Dispatch(message NotificationMessage) error {
+	var errs []error
+	for _, name := range nr.routes[message.Event] {
+		if err := nr.Send(message, name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Send delivers a message on one channel, whatever the event's route, subject to the policy
+func (nr *NotificationRouter) Send(message NotificationMessage, name string) error {
+	channel, ok := nr.channels[name]
+	if !ok {
+		return nil
+	}
+
+	if nr.policy != nil {
+		var deliver bool
+		var err error
+		if message, deliver, err = nr.policy.Apply(message, name); err != nil || !deliver {
+			return err
+		}
+	}
+	return channel.Notify(message)
+}
+
+// Publish adds a message to the outbox, as part of tx, for each registered channel its event is routed to
+func (nr *NotificationRouter) Publish(tx *gorm.DB, message NotificationMessage) error {
+	return nr.PublishTo(tx, message, nr.routes[message.Event]...)
+}
+
+// PublishTo adds a message to the outbox, as part of tx, for the given channels that are registered
+func (nr *NotificationRouter) PublishTo(tx *gorm.DB, message NotificationMessage, names ...string) error {
+	channels := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, ok := nr.channels[name]; ok {
+			channels = append(channels, name)
+		}
+	}
+
+	outboxService := &OutboxService{}
+	return outboxService.Add(tx, message, channels...)
+}
+
+var (
+	notificationRouter   *NotificationRouter
+	notificationRouterMu sync.RWMutex
+)
+
+// InitNotifications builds the notification channels from config and starts the outbox workers
+func InitNotifications() {
+	SetNotificationRouter(DefaultNotificationRouter())
+
+	outboxService := &OutboxService{}
+	outboxService.Start(max(config.AppConfig.NotifyWorkers, 1))
+}
+
+// DefaultNotificationRouter routes DefaultNotificationRoutes to the channels configured in config
+func DefaultNotificationRouter() *NotificationRouter {
+	channels := map[string]Notifier{
+		ChannelEmail:   NewSMTPNotifier(),
+		ChannelInApp:   &InAppNotifier{},
+		ChannelWebhook: &WebhookNotifier{},
+	}
+	return NewNotificationRouter(DefaultNotificationRoutes, channels).WithPolicy(&NotificationPreferenceService{})
+}
+
+// SetNotificationRouter replaces the router used for all notifications, e.g. with recording fakes
+func SetNotificationRouter(router *NotificationRouter) {
+	notificationRouterMu.Lock()
+	defer notificationRouterMu.Unlock()
+	notificationRouter = router
+}
+
+// Notifications returns the active router, building the default one if none was set
+func Notifications() *NotificationRouter {
+	notificationRouterMu.RLock()
+	router := notificationRouter
+	notificationRouterMu.RUnlock()
+	if router != nil {
+		return router
+	}
+
+	notificationRouterMu.Lock()
+	defer notificationRouterMu.Unlock()
+	if notificationRouter == nil {
+		notificationRouter = DefaultNotificationRouter()
+	}
+	return notificationRouter
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SMTPNotifier sends notifications as email, with an HTML alternative when the message has one
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPNotifier creates an SMTP notifier from config
+func NewSMTPNotifier() *SMTPNotifier {
+	return &SMTPNotifier{
+		Host:     config.AppConfig.SMTPHost,
+		Port:     config.AppConfig.SMTPPort,
+		Username: config.AppConfig.SMTPUser,
+		Password: config.AppConfig.SMTPPass,
+		From:     config.AppConfig.SMTPFrom,
+	}
+}
+
+// Notify emails the message to its recipient's address
+func (sn *SMTPNotifier) Notify(message NotificationMessage) error {
+	if message.Email == "" {
+		return fmt.Errorf("no email address for user %d", message.UserID)
+	}
+
+	// Skip sending if credentials not configured
+	if sn.Username == "" || sn.Password == "" {
+		log.Printf("Email not sent to %s: SMTP credentials not configured", message.Email)
+		return nil
+	}
+
+	body, err := sn.buildMessage(message)
+	if err != nil {
+		return err
+	}
+	auth := smtp.PlainAuth("", sn.Username, sn.Password, sn.Host)
+
+	if err := smtp.SendMail(sn.Host+":"+sn.Port, auth, sn.From, []string{message.Email}, body); err != nil {
+		return err
+	}
+
+	log.Printf("Email sent successfully to %s", message.Email)
+	return nil
+}
+
+// buildMessage encodes the message as MIME: plain text, or multipart/alternative with an HTML part
+func (sn *SMTPNotifier) buildMessage(message NotificationMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n",
+		sn.From, message.Email, mime.QEncoding.Encode("utf-8", message.Subject))
+
+	text, html := message.Body, message.HTMLBody
+	if message.UnsubscribeURL != "" {
+		// RFC 8058 one-click unsubscribe: mail clients POST to the URL without opening it
+		fmt.Fprintf(&buf, "List-Unsubscribe: <%s>\r\nList-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n", message.UnsubscribeURL)
+
+		prompt := Translate(message.Locale, "email.unsubscribe_prompt")
+		label := Translate(message.Locale, "email.unsubscribe")
+		text += fmt.Sprintf("\n%s %s: %s\n", prompt, label, message.UnsubscribeURL)
+		if html != "" {
+			footer := fmt.Sprintf(`<p style="text-align:center;font-size:12px;color:#7b8794;">%s <a href="%s">%s</a></p>`,
+				template.HTMLEscapeString(prompt), template.HTMLEscapeString(message.UnsubscribeURL), template.HTMLEscapeString(label))
+			if i := strings.LastIndex(html, "</body>"); i >= 0 {
+				html = html[:i] + footer + "\n" + html[i:]
+			} else {
+				html += footer
+			}
+		}
+	}
+
+	if html == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+
+	// Clients show the last part they understand, so HTML goes after the plain text
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(writer, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// InAppNotifier stores notifications for the notification feed
+type InAppNotifier struct{}
+
+// Notify creates an in-app notification for the recipient
+func (in *InAppNotifier) Notify(message NotificationMessage) error {
+	notificationService := &NotificationService{}
+
+	var data interface{}
+	if message.Data != nil {
+		data = message.Data
+	}
+
+	_, err := notificationService.Create(message.UserID, message.Event, message.Subject, message.Summary, message.Link, data)
+	return err
+}
+
+// WebhookNotifier posts notifications to webhook subscriptions. The outbox gives each subscription
+// its own copy of a message, naming it in WebhookID.
+type WebhookNotifier struct{}
+
+// Notify posts the message to its subscription; subscriptions deleted or paused since are skipped
+func (wn *WebhookNotifier) Notify(message NotificationMessage) error {
+	if message.WebhookID == 0 {
+		return nil
+	}
+
+	var subscription models.WebhookSubscription
+	if err := config.DB.First(&subscription, message.WebhookID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !subscription.IsActive {
+		return nil
+	}
+
+	webhookService := &WebhookService{}
+	_, err := webhookService.Deliver(subscription, message)
+	return err
+}
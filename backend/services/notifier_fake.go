@@ -0,0 +1,51 @@
+package services
+
+import "sync"
+
+// RecordingNotifier is a fake channel that records messages instead of delivering them.
+// Register it in place of real channels to check what would have been sent:
+//
+//	email := &RecordingNotifier{}
+//	SetNotificationRouter(NewNotificationRouter(DefaultNotificationRoutes, map[string]Notifier{ChannelEmail: email}))
+type RecordingNotifier struct {
+	Err error // Returned from every Notify call when set
+
+	mu       sync.Mutex
+	messages []NotificationMessage
+}
+
+// Notify records the message
+func (rn *RecordingNotifier) Notify(message NotificationMessage) error {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.messages = append(rn.messages, message)
+	return rn.Err
+}
+
+// Messages returns a copy of the recorded messages, oldest first
+func (rn *RecordingNotifier) Messages() []NotificationMessage {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	return append([]NotificationMessage(nil), rn.messages...)
+}
+
+// MessagesFor returns the recorded messages for one event
+func (rn *RecordingNotifier) MessagesFor(event string) []NotificationMessage {
+	var matching []NotificationMessage
+	for _, message := range rn.Messages() {
+		if message.Event == event {
+			matching = append(matching, message)
+		}
+	}
+	return matching
+}
+
+// Reset forgets the recorded messages
+func (rn *RecordingNotifier) Reset() {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.messages = nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import "testing"
+
+func TestRouterDeliversToRecordingNotifier(t *testing.T) {
+	email := &RecordingNotifier{}
+	inApp := &RecordingNotifier{}
+	router := NewNotificationRouter(DefaultNotificationRoutes, map[string]Notifier{
+		ChannelEmail: email,
+		ChannelInApp: inApp,
+	})
+
+	message := NotificationMessage{Event: EventAccountLocked, UserID: 7, Email: "user@example.com", Subject: "Locked"}
+	if err := router.Dispatch(message); err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+
+	// Account lockouts are routed to email only
+	recorded := email.MessagesFor(EventAccountLocked)
+	if len(recorded) != 1 || recorded[0].UserID != 7 || recorded[0].Subject != "Locked" {
+		t.Fatalf("email recorded %+v, want the lockout message", recorded)
+	}
+	if got := inApp.Messages(); len(got) != 0 {
+		t.Fatalf("in-app recorded %+v, want nothing", got)
+	}
+
+	email.Reset()
+	if got := email.Messages(); len(got) != 0 {
+		t.Fatalf("after Reset recorded %+v, want nothing", got)
+	}
+}
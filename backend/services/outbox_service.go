@@ -0,0 +1,291 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Outbox message statuses
+const (
+	OutboxPending    = "pending"
+	OutboxProcessing = "processing"
+	OutboxDelivered  = "delivered"
+	OutboxDead       = "dead"
+)
+
+const (
+	// How often idle workers look for due messages
+	outboxPollInterval = time.Second
+	// How long a worker owns a claimed message; after that another worker may retry it
+	outboxLockDuration = 5 * time.Minute
+	// Retry delays start here and double with each failed attempt, up to outboxMaxBackoff
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+	// Delivered messages are kept this long for inspection
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// OutboxService stores notifications alongside the changes they announce and delivers them with
+// retries. Delivery is at least once: a worker that stops mid-send leaves the message to be sent again.
+type OutboxService struct{}
+
+// Add writes a message to the outbox for each channel, as part of tx. The webhook channel gets a
+// copy for each subscription to the event, so every subscription is retried on its own.
+func (obs *OutboxService) Add(tx *gorm.DB, message NotificationMessage, channels ...string) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	if message.ID == "" {
+		message.ID = newEventID()
+	}
+
+	webhookService := &WebhookService{}
+	var rows []models.OutboxMessage
+	for _, channel := range channels {
+		if channel != ChannelWebhook {
+			row, err := obs.newRow(message, channel)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+			continue
+		}
+
+		subscriptions, err := webhookService.Subscribers(tx, message)
+		if err != nil {
+			return err
+		}
+		for _, subscription := range subscriptions {
+			subscriptionID := subscription.ID
+			forSubscription := message
+			forSubscription.WebhookID = subscriptionID
+			row, err := obs.newRow(forSubscription, channel)
+			if err != nil {
+				return err
+			}
+			row.WebhookID = &subscriptionID
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+func (obs *OutboxService) newRow(message NotificationMessage, channel string) (models.OutboxMessage, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return models.OutboxMessage{}, err
+	}
+
+	return models.OutboxMessage{
+		Event:         message.Event,
+		Channel:       channel,
+		UserID:        message.UserID,
+		Payload:       string(payload),
+		Status:        OutboxPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// Start launches workers that deliver due messages until the process exits. Every instance may run
+// them; each message is claimed by one worker at a time.
+func (obs *OutboxService) Start(workers int) {
+	claimed := make(chan models.OutboxMessage)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for row := range claimed {
+				obs.deliver(row)
+			}
+		}()
+	}
+
+	go func() {
+		for {
+			// Claim no more than the workers can take, so claimed messages don't wait out their lock
+			rows, err := obs.claim(workers)
+			if err != nil {
+				log.Printf("Failed to claim outbox messages: %v", err)
+			}
+			for _, row := range rows {
+				claimed <- row
+			}
+			if len(rows) < workers {
+				time.Sleep(outboxPollInterval)
+			}
+		}
+	}()
+}
+
+// claim marks up to limit due messages as processing by this instance. Messages whose worker
+// stopped before finishing are due again once their lock expires.
+func (obs *OutboxService) claim(limit int) ([]models.OutboxMessage, error) {
+	now := time.Now()
+
+	var rows []models.OutboxMessage
+	err := config.DB.Raw(`
+		UPDATE outbox_messages
+		SET status = ?, locked_until = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_messages
+			WHERE (status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?)
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		OutboxProcessing, now.Add(outboxLockDuration), now,
+		OutboxPending, now, OutboxProcessing, now,
+		limit,
+	).Scan(&rows).Error
+	return rows, err
+}
+
+func (obs *OutboxService) deliver(row models.OutboxMessage) {
+	var message NotificationMessage
+	if err := json.Unmarshal([]byte(row.Payload), &message); err != nil {
+		obs.markDead(row, fmt.Errorf("malformed payload: %w", err))
+		return
+	}
+
+	// A worker stopped during each earlier attempt; don't let this message take down another
+	if row.Attempts > obs.maxAttempts() {
+		obs.markDead(row, errors.New("delivery was interrupted too many times"))
+		return
+	}
+
+	if err := Notifications().Send(message, row.Channel); err != nil {
+		obs.retry(row, err)
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&row).Updates(map[string]interface{}{
+		"status":       OutboxDelivered,
+		"delivered_at": now,
+		"locked_until": nil,
+	}).Error; err != nil {
+		log.Printf("Failed to mark outbox message %d delivered: %v", row.ID, err)
+	}
+}
+
+// retry schedules another attempt after a failed one, or dead-letters the message when it has none left
+func (obs *OutboxService) retry(row models.OutboxMessage, cause error) {
+	if row.Attempts >= obs.maxAttempts() {
+		obs.markDead(row, cause)
+		return
+	}
+
+	delay := outboxBackoff(row.Attempts)
+	log.Printf("Failed to deliver %s to user %d over %s (attempt %d), retrying in %s: %v",
+		row.Event, row.UserID, row.Channel, row.Attempts, delay.Round(time.Second), cause)
+
+	if err := config.DB.Model(&row).Updates(map[string]interface{}{
+		"status":          OutboxPending,
+		"next_attempt_at": time.Now().Add(delay),
+		"locked_until":    nil,
+		"last_error":      cause.Error(),
+	}).Error; err != nil {
+		log.Printf("Failed to reschedule outbox message %d: %v", row.ID, err)
+	}
+}
+
+func (obs *OutboxService) markDead(row models.OutboxMessage, cause error) {
+	log.Printf("Giving up on %s to user %d over %s after %d attempts: %v",
+		row.Event, row.UserID, row.Channel, row.Attempts, cause)
+
+	if err := config.DB.Model(&row).Updates(map[string]interface{}{
+		"status":       OutboxDead,
+		"locked_until": nil,
+		"last_error":   cause.Error(),
+	}).Error; err != nil {
+		log.Printf("Failed to dead-letter outbox message %d: %v", row.ID, err)
+	}
+}
+
+func (obs *OutboxService) maxAttempts() int {
+	return max(config.AppConfig.NotifyMaxAttempts, 1)
+}
+
+// outboxBackoff returns the delay before the attempt after the given one, with up to 20% jitter
+// so messages that failed together don't all retry together
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxMaxBackoff
+	if attempts >= 1 && attempts <= 8 {
+		if shifted := outboxBaseBackoff << (attempts - 1); shifted < delay {
+			delay = shifted
+		}
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+}
+
+// Replay queues a dead message for immediate delivery with a fresh set of attempts
+func (obs *OutboxService) Replay(id uint) error {
+	result := config.DB.Model(&models.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, OutboxDead).
+		Updates(obs.replayUpdates())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReplayDead queues every dead message, optionally only for one event and channel, and returns how many
+func (obs *OutboxService) ReplayDead(event, channel string) (int64, error) {
+	query := config.DB.Model(&models.OutboxMessage{}).Where("status = ?", OutboxDead)
+	if event != "" {
+		query = query.Where("event = ?", event)
+	}
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
+	result := query.Updates(obs.replayUpdates())
+	return result.RowsAffected, result.Error
+}
+
+func (obs *OutboxService) replayUpdates() map[string]interface{} {
+	// Keep last_error, so it's clear why the message needed replaying
+	return map[string]interface{}{
+		"status":          OutboxPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}
+}
+
+// StatusCounts returns how many messages are in each status
+func (obs *OutboxService) StatusCounts() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := config.DB.Model(&models.OutboxMessage{}).Select("status, COUNT(*) AS count").
+		Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{OutboxPending: 0, OutboxProcessing: 0, OutboxDelivered: 0, OutboxDead: 0}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// PurgeDelivered deletes delivered messages past the retention period; dead ones stay until replayed
+func (obs *OutboxService) PurgeDelivered() error {
+	return config.DB.Where("status = ? AND delivered_at < ?", OutboxDelivered, time.Now().Add(-outboxRetention)).
+		Delete(&models.OutboxMessage{}).Error
+}
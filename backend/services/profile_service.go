@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type ProfileService struct{}
+
+// Exchange statuses that connect two users for "matched" profile visibility
+var connectedExchangeStatuses = []string{"accepted", "completed"}
+
+// GetPrivacySettings returns the user's privacy settings, or the defaults if they never changed them
+func (ps *ProfileService) GetPrivacySettings(userID uint) models.PrivacySettings {
+	settings := models.PrivacySettings{
+		UserID:            userID,
+		LocationPrecision: "city",
+		ShowReviews:       true,
+		ProfileVisibility: "everyone",
+	}
+	config.DB.Where("user_id = ?", userID).First(&settings)
+	return settings
+}
+
+// CanView reports whether viewerID may see ownerID's profile. A nil viewer is a logged-out visitor.
+func (ps *ProfileService) CanView(settings models.PrivacySettings, viewerID *uint) bool {
+	if viewerID == nil {
+		return settings.PublicProfileEnabled && settings.ProfileVisibility == "everyone"
+	}
+
+	if *viewerID == settings.UserID {
+		return true
+	}
+
+	switch settings.ProfileVisibility {
+	case "everyone":
+		return true
+	case "matched":
+		return ps.areConnected(settings.UserID, *viewerID)
+	default:
+		return false
+	}
+}
+
+// ViewableBy restricts query to rows whose userColumn is a user whose profile viewerID may see.
+// It's CanView for a logged-in viewer in SQL, so lists can filter before paginating.
+func (ps *ProfileService) ViewableBy(query *gorm.DB, userColumn string, viewerID uint) *gorm.DB {
+	visibility := fmt.Sprintf("COALESCE((SELECT profile_visibility FROM privacy_settings WHERE privacy_settings.user_id = %s), 'everyone')", userColumn)
+	connected := fmt.Sprintf("EXISTS (SELECT 1 FROM exchanges JOIN skills ON exchanges.skill_id = skills.id"+
+		" WHERE exchanges.deleted_at IS NULL AND exchanges.status IN ?"+
+		" AND ((exchanges.requester_id = %[1]s AND skills.user_id = ?) OR (exchanges.requester_id = ? AND skills.user_id = %[1]s)))", userColumn)
+
+	return query.Where(fmt.Sprintf("%s = ? OR %s = 'everyone' OR (%s = 'matched' AND %s)", userColumn, visibility, visibility, connected),
+		viewerID, connectedExchangeStatuses, viewerID, viewerID)
+}
+
+// BuildPublicProfile assembles the curated profile DTO according to the owner's privacy settings
+func (ps *ProfileService) BuildPublicProfile(user models.User, settings models.PrivacySettings) models.PublicProfile {
+	profile := models.PublicProfile{
+		ID:            user.ID,
+		Username:      user.Username,
+		FullName:      user.FullName,
+		Bio:           user.Bio,
+		Avatar:        user.Avatar,
+		Location:      ps.applyLocationPrecision(user.Location, settings.LocationPrecision),
+		MemberSince:   user.CreatedAt,
+		OfferedSkills: []models.Skill{},
+	}
+
+	if settings.ShowEmail {
+		profile.Email = user.Email
+	}
+
+	config.DB.Where("user_id = ? AND skill_type = ? AND is_active = ?", user.ID, "offering", true).
+		Order("created_at DESC").Find(&profile.OfferedSkills)
+
+	var rating models.UserRating
+	if err := config.DB.Where("user_id = ?", user.ID).First(&rating).Error; err == nil {
+		profile.Rating = models.RatingSummary{
+			AverageRating: rating.AverageRating,
+			TotalReviews:  rating.TotalReviews,
+			Distribution:  [5]int{rating.Rating1Count, rating.Rating2Count, rating.Rating3Count, rating.Rating4Count, rating.Rating5Count},
+		}
+	}
+
+	config.DB.Model(&models.Exchange{}).
+		Joins("JOIN skills ON exchanges.skill_id = skills.id").
+		Where("(exchanges.requester_id = ? OR skills.user_id = ?) AND exchanges.status = ?", user.ID, user.ID, "completed").
+		Count(&profile.CompletedExchanges)
+
+	if settings.ShowReviews {
+		config.DB.Where("reviewee_id = ?", user.ID).
+			Preload("Reviewer", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username", "full_name", "avatar") }).
+			Order("created_at DESC").Limit(5).Find(&profile.RecentReviews)
+	}
+
+	return profile
+}
+
+// areConnected reports whether two users have an accepted or completed exchange with each other.
+// Pending requests and chats don't count; anyone can start those.
+func (ps *ProfileService) areConnected(userID1, userID2 uint) bool {
+	var count int64
+	config.DB.Model(&models.Exchange{}).
+		Joins("JOIN skills ON exchanges.skill_id = skills.id").
+		Where("(exchanges.requester_id = ? AND skills.user_id = ?) OR (exchanges.requester_id = ? AND skills.user_id = ?)",
+			userID1, userID2, userID2, userID1).
+		Where("exchanges.status IN ?", connectedExchangeStatuses).
+		Count(&count)
+	return count > 0
+}
+
+// applyLocationPrecision trims a free-text "City, Region, Country" location
+func (ps *ProfileService) applyLocationPrecision(location, precision string) string {
+	parts := strings.Split(location, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	switch precision {
+	case "exact":
+		return location
+	case "city":
+		return parts[0]
+	case "country":
+		if len(parts) > 1 {
+			return parts[len(parts)-1]
+		}
+		return ""
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"skillswap-backend/config"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Postgres channel that carries push events between API instances
+const pushChannel = "skillswap_push"
+
+// Postgres rejects NOTIFY payloads of 8000 bytes or more; larger events only reach this instance
+const maxPushPayload = 7900
+
+// Events buffered per open stream before a slow client starts missing them
+const pushBufferSize = 32
+
+// PushEvent is sent to a user's open event streams
+type PushEvent struct {
+	Type string          `json:"type"` // e.g. message.created, notification.created
+	Data json.RawMessage `json:"data"`
+}
+
+// pushEnvelope is a push event addressed to a user, as sent over NOTIFY
+type pushEnvelope struct {
+	UserID uint      `json:"u"`
+	Event  PushEvent `json:"e"`
+}
+
+// PushHub delivers live events to users' open streams. With Listen running, events travel through
+// Postgres LISTEN/NOTIFY so a user connected to any instance receives them.
+type PushHub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan PushEvent]struct{}
+	listening   bool
+}
+
+var pushHub = &PushHub{subscribers: make(map[uint]map[chan PushEvent]struct{})}
+
+// Push returns the process-wide push hub
+func Push() *PushHub {
+	return pushHub
+}
+
+// Subscribe opens a stream of a user's events. Call the returned function to close it.
+func (ph *PushHub) Subscribe(userID uint) (<-chan PushEvent, func()) {
+	events := make(chan PushEvent, pushBufferSize)
+
+	ph.mu.Lock()
+	if ph.subscribers[userID] == nil {
+		ph.subscribers[userID] = make(map[chan PushEvent]struct{})
+	}
+	ph.subscribers[userID][events] = struct{}{}
+	ph.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			ph.mu.Lock()
+			delete(ph.subscribers[userID], events)
+			if len(ph.subscribers[userID]) == 0 {
+				delete(ph.subscribers, userID)
+			}
+			ph.mu.Unlock()
+		})
+	}
+}
+
+// Publish sends an event to every open stream of a user
+func (ph *PushHub) Publish(userID uint, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode %s push event: %v", eventType, err)
+		return
+	}
+	envelope := pushEnvelope{UserID: userID, Event: PushEvent{Type: eventType, Data: payload}}
+
+	ph.mu.RLock()
+	listening := ph.listening
+	ph.mu.RUnlock()
+
+	if listening {
+		message, err := json.Marshal(envelope)
+		if err == nil && len(message) <= maxPushPayload {
+			if err := config.DB.Exec("SELECT pg_notify(?, ?)", pushChannel, string(message)).Error; err == nil {
+				return // Delivered locally by Listen, like on every other instance
+			}
+		}
+	}
+
+	ph.deliver(envelope)
+}
+
+// deliver hands an event to this instance's streams for the user, dropping it for streams that are full
+func (ph *PushHub) deliver(envelope pushEnvelope) {
+	ph.mu.RLock()
+	defer ph.mu.RUnlock()
+
+	for events := range ph.subscribers[envelope.UserID] {
+		select {
+		case events <- envelope.Event:
+		default:
+		}
+	}
+}
+
+// Listen receives events published by every instance and delivers them to local streams. It
+// reconnects when the connection drops and never returns.
+func (ph *PushHub) Listen() {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := ph.listen()
+
+		ph.mu.Lock()
+		ph.listening = false
+		ph.mu.Unlock()
+
+		// Start over after a connection that stayed up a while, otherwise back off up to a minute
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("Push listener stopped, retrying in %s: %v", backoff, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+func (ph *PushHub) listen() error {
+	ctx := context.Background()
+
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+pushChannel); err != nil {
+			return err
+		}
+		// Don't hand a listening connection back to the pool
+		defer pgConn.Exec(ctx, "UNLISTEN *")
+
+		ph.mu.Lock()
+		ph.listening = true
+		ph.mu.Unlock()
+
+		for {
+			notification, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			var envelope pushEnvelope
+			if err := json.Unmarshal([]byte(notification.Payload), &envelope); err != nil {
+				log.Printf("Ignoring malformed push event: %v", err)
+				continue
+			}
+			ph.deliver(envelope)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// RankingModel is a logistic regression over standardized ranking features.
+// It is trained offline by cmd/train-ranker and saved as a JSON artifact.
+type RankingModel struct {
+	Version          string             `json:"version"`
+	Algorithm        string             `json:"algorithm"`
+	TrainedAt        time.Time          `json:"trained_at"`
+	FeatureNames     []string           `json:"feature_names"`
+	Means            []float64          `json:"means"`
+	Stds             []float64          `json:"stds"`
+	Weights          []float64          `json:"weights"`
+	Bias             float64            `json:"bias"`
+	TrainingExamples int                `json:"training_examples"`
+	Metrics          map[string]float64 `json:"metrics"`
+}
+
+// TrainingExample is one (seeker, offered skill) pair with its observed outcome
+type TrainingExample struct {
+	Features []float64
+	Label    float64 // 1 for a good outcome, 0 otherwise
+	AsOf     time.Time
+}
+
+// TrainingOptions controls gradient descent
+type TrainingOptions struct {
+	Epochs       int
+	LearningRate float64
+	L2           float64
+}
+
+// TrainLogisticRegression fits a model with full-batch gradient descent and L2 regularization
+func TrainLogisticRegression(examples []TrainingExample, featureNames []string, opts TrainingOptions) (*RankingModel, error) {
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("no training examples")
+	}
+
+	n := len(featureNames)
+	for _, example := range examples {
+		if len(example.Features) != n {
+			return nil, fmt.Errorf("example has %d features, expected %d", len(example.Features), n)
+		}
+	}
+
+	model := &RankingModel{
+		Version:          time.Now().UTC().Format("20060102T150405Z"),
+		Algorithm:        "logistic_regression",
+		TrainedAt:        time.Now().UTC(),
+		FeatureNames:     featureNames,
+		Means:            make([]float64, n),
+		Stds:             make([]float64, n),
+		Weights:          make([]float64, n),
+		TrainingExamples: len(examples),
+	}
+
+	// Standardize features so one learning rate suits all of them
+	for _, example := range examples {
+		for j, value := range example.Features {
+			model.Means[j] += value
+		}
+	}
+	for j := range model.Means {
+		model.Means[j] /= float64(len(examples))
+	}
+	for _, example := range examples {
+		for j, value := range example.Features {
+			d := value - model.Means[j]
+			model.Stds[j] += d * d
+		}
+	}
+	for j := range model.Stds {
+		model.Stds[j] = math.Sqrt(model.Stds[j] / float64(len(examples)))
+		if model.Stds[j] < 1e-9 {
+			model.Stds[j] = 1 // Constant feature; leave it unscaled
+		}
+	}
+
+	standardized := make([][]float64, len(examples))
+	for i, example := range examples {
+		standardized[i] = model.standardize(example.Features)
+	}
+
+	gradient := make([]float64, n)
+	for epoch := 0; epoch < opts.Epochs; epoch++ {
+		for j := range gradient {
+			gradient[j] = 0
+		}
+		biasGradient := 0.0
+
+		for i, x := range standardized {
+			err := sigmoid(model.linear(x)) - examples[i].Label
+			for j, value := range x {
+				gradient[j] += err * value
+			}
+			biasGradient += err
+		}
+
+		m := float64(len(examples))
+		for j := range model.Weights {
+			model.Weights[j] -= opts.LearningRate * (gradient[j]/m + opts.L2*model.Weights[j])
+		}
+		model.Bias -= opts.LearningRate * biasGradient / m
+	}
+
+	return model, nil
+}
+
+// Predict returns the probability of a good outcome for a raw feature vector
+func (rm *RankingModel) Predict(features []float64) float64 {
+	return sigmoid(rm.linear(rm.standardize(features)))
+}
+
+// Evaluate computes log loss, accuracy and ROC AUC on labeled examples
+func (rm *RankingModel) Evaluate(examples []TrainingExample) map[string]float64 {
+	metrics := map[string]float64{"examples": float64(len(examples))}
+	if len(examples) == 0 {
+		return metrics
+	}
+
+	type scored struct {
+		p     float64
+		label float64
+	}
+	predictions := make([]scored, len(examples))
+
+	logLoss, correct := 0.0, 0
+	for i, example := range examples {
+		p := rm.Predict(example.Features)
+		predictions[i] = scored{p, example.Label}
+
+		clipped := math.Min(math.Max(p, 1e-12), 1-1e-12)
+		logLoss -= example.Label*math.Log(clipped) + (1-example.Label)*math.Log(1-clipped)
+		if (p >= 0.5) == (example.Label == 1) {
+			correct++
+		}
+	}
+	metrics["log_loss"] = logLoss / float64(len(examples))
+	metrics["accuracy"] = float64(correct) / float64(len(examples))
+
+	// AUC via the rank-sum formulation, averaging ranks of tied scores
+	sort.Slice(predictions, func(i, j int) bool { return predictions[i].p < predictions[j].p })
+	positives, negatives, rankSum := 0.0, 0.0, 0.0
+	for i := 0; i < len(predictions); {
+		j := i
+		for j < len(predictions) && predictions[j].p == predictions[i].p {
+			j++
+		}
+		averageRank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if predictions[k].label == 1 {
+				positives++
+				rankSum += averageRank
+			} else {
+				negatives++
+			}
+		}
+		i = j
+	}
+	if positives > 0 && negatives > 0 {
+		metrics["auc"] = (rankSum - positives*(positives+1)/2) / (positives * negatives)
+	}
+
+	return metrics
+}
+
+// Save writes the model as a versioned artifact in dir and returns its path
+func (rm *RankingModel) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(rm, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("ranking_model_%s.json", rm.Version))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Promote makes this model the one loaded by the API (ranking_model.json in dir)
+func (rm *RankingModel) Promote(dir string) error {
+	data, err := json.MarshalIndent(rm, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a running instance never reads a partial file
+	current := filepath.Join(dir, currentRankingModelFile)
+	tmp := current + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, current)
+}
+
+// LoadRankingModel reads a model artifact and checks it was trained on the current feature set
+func LoadRankingModel(path string) (*RankingModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var model RankingModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("invalid ranking model %s: %w", path, err)
+	}
+
+	if len(model.FeatureNames) != len(RankingFeatureNames) {
+		return nil, fmt.Errorf("ranking model %s has %d features, expected %d", model.Version, len(model.FeatureNames), len(RankingFeatureNames))
+	}
+	for i, name := range RankingFeatureNames {
+		if model.FeatureNames[i] != name {
+			return nil, fmt.Errorf("ranking model %s feature %d is %q, expected %q", model.Version, i, model.FeatureNames[i], name)
+		}
+	}
+	if len(model.Weights) != len(model.FeatureNames) || len(model.Means) != len(model.FeatureNames) || len(model.Stds) != len(model.FeatureNames) {
+		return nil, fmt.Errorf("ranking model %s is incomplete", model.Version)
+	}
+	// Scoring divides by the standard deviations, so a hand-edited or corrupt model must not slip through
+	for j, std := range model.Stds {
+		if math.IsNaN(std) || math.IsInf(std, 0) || std <= 0 {
+			return nil, fmt.Errorf("ranking model %s has invalid standard deviation %v for %q", model.Version, std, model.FeatureNames[j])
+		}
+		if !isFinite(model.Means[j]) || !isFinite(model.Weights[j]) {
+			return nil, fmt.Errorf("ranking model %s has a non-finite mean or weight for %q", model.Version, model.FeatureNames[j])
+		}
+	}
+	if !isFinite(model.Bias) {
+		return nil, fmt.Errorf("ranking model %s has a non-finite bias", model.Version)
+	}
+
+	return &model, nil
+}
+
+func (rm *RankingModel) standardize(features []float64) []float64 {
+	x := make([]float64, len(features))
+	for j, value := range features {
+		x[j] = (value - rm.Means[j]) / rm.Stds[j]
+	}
+	return x
+}
+
+func (rm *RankingModel) linear(x []float64) float64 {
+	z := rm.Bias
+	for j, value := range x {
+		z += rm.Weights[j] * value
+	}
+	return z
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// File in RANKING_MODEL_DIR that holds the model served by the API
+const currentRankingModelFile = "ranking_model.json"
+
+// Match points awarded for a predicted probability of 1
+const rankingModelScoreScale = 50
+
+// RankingFeatureNames lists the model inputs in order; changing it invalidates saved models
+var RankingFeatureNames = []string{
+	"category_history",
+	"category_fit",
+	"level_fit",
+	"teacher_rating",
+	"teacher_review_count",
+	"teacher_completion_rate",
+	"teacher_activity",
+	"skill_endorsements",
+	"mutual_interest",
+	"bookmarked",
+	"follows_teacher",
+	"category_feedback",
+}
+
+var (
+	rankingModel   *RankingModel
+	rankingModelMu sync.RWMutex
+)
+
+type RankingService struct{}
+
+// InitRankingModel loads the current model from RANKING_MODEL_DIR; without one matching uses heuristics
+func InitRankingModel() error {
+	model, err := LoadRankingModel(filepath.Join(config.AppConfig.RankingModelDir, currentRankingModelFile))
+	if err != nil {
+		return err
+	}
+
+	SetRankingModel(model)
+	log.Printf("Loaded ranking model %s", model.Version)
+	return nil
+}
+
+// SetRankingModel replaces the model used for scoring; nil restores heuristic scoring
+func SetRankingModel(model *RankingModel) {
+	rankingModelMu.Lock()
+	defer rankingModelMu.Unlock()
+	rankingModel = model
+}
+
+// ActiveRankingModel returns the loaded model, or nil when heuristics are in use
+func ActiveRankingModel() *RankingModel {
+	rankingModelMu.RLock()
+	defer rankingModelMu.RUnlock()
+	return rankingModel
+}
+
+// ScoreAll returns the model's recommendation points for a seeker and each offered skill as of asOf,
+// keyed by skill ID. It's nil without a model.
+func (rs *RankingService) ScoreAll(seeker models.User, offeredSkills []models.Skill, asOf time.Time) map[uint]int {
+	model := ActiveRankingModel()
+	if model == nil {
+		return nil
+	}
+
+	scores := make(map[uint]int, len(offeredSkills))
+	for skillID, features := range rs.Features(seeker, offeredSkills, asOf) {
+		scores[skillID] = int(math.Round(model.Predict(features) * rankingModelScoreScale))
+	}
+	return scores
+}
+
+// Features describes (seeker, offered skill) pairs, keyed by skill ID, using only data that existed
+// at asOf, so historical pairs can be replayed without leaking their own outcome. Locations aren't
+// used because only current ones are stored. The queries are grouped, so their number doesn't grow
+// with the number of skills.
+func (rs *RankingService) Features(seeker models.User, offeredSkills []models.Skill, asOf time.Time) map[uint][]float64 {
+	features := make(map[uint][]float64, len(offeredSkills))
+	if len(offeredSkills) == 0 {
+		return features
+	}
+
+	ms := &MatchService{}
+	skillIDs := make([]uint, 0, len(offeredSkills))
+	teacherIDs := make([]uint, 0, len(offeredSkills))
+	for _, skill := range offeredSkills {
+		skillIDs = append(skillIDs, skill.ID)
+		teacherIDs = append(teacherIDs, skill.UserID)
+	}
+	teacherIDs = uniqueIDs(teacherIDs)
+
+	// Seeker's past exchanges per category
+	var historyRows []struct {
+		Category string
+		Count    int64
+	}
+	config.DB.Model(&models.Exchange{}).
+		Select("skills.category AS category, COUNT(*) AS count").
+		Joins("JOIN skills ON skills.id = exchanges.skill_id").
+		Where("exchanges.requester_id = ? AND exchanges.created_at < ?", seeker.ID, asOf).
+		Group("skills.category").
+		Scan(&historyRows)
+	categoryHistory := make(map[string]int64, len(historyRows))
+	for _, row := range historyRows {
+		categoryHistory[row.Category] = row.Count
+	}
+
+	seekerSeeking := rs.skillsAsOf([]uint{seeker.ID}, "seeking", asOf)
+
+	// Teacher reputation
+	var reviewRows []struct {
+		RevieweeID uint
+		Average    float64
+		Count      int64
+	}
+	config.DB.Model(&models.Review{}).
+		Select("reviewee_id, AVG(rating) AS average, COUNT(*) AS count").
+		Where("reviewee_id IN ? AND created_at < ?", teacherIDs, asOf).
+		Group("reviewee_id").
+		Scan(&reviewRows)
+	reviewAverages := make(map[uint]float64, len(reviewRows))
+	reviewCounts := make(map[uint]int64, len(reviewRows))
+	for _, row := range reviewRows {
+		reviewAverages[row.RevieweeID] = row.Average
+		reviewCounts[row.RevieweeID] = row.Count
+	}
+
+	// Exchanges each teacher took part in, as requester or as skill owner
+	type exchangeCounts struct {
+		UserID    uint
+		Total     int64
+		Completed int64
+	}
+	var asRequester, asOwner []exchangeCounts
+	config.DB.Model(&models.Exchange{}).
+		Select("requester_id AS user_id, COUNT(*) AS total, SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) AS completed").
+		Where("requester_id IN ? AND created_at < ?", teacherIDs, asOf).
+		Group("requester_id").
+		Scan(&asRequester)
+	config.DB.Model(&models.Exchange{}).
+		Select("skills.user_id AS user_id, COUNT(*) AS total, SUM(CASE WHEN exchanges.status = 'completed' THEN 1 ELSE 0 END) AS completed").
+		Joins("JOIN skills ON exchanges.skill_id = skills.id").
+		Where("skills.user_id IN ? AND exchanges.created_at < ?", teacherIDs, asOf).
+		Group("skills.user_id").
+		Scan(&asOwner)
+	teacherTotal := make(map[uint]int64, len(teacherIDs))
+	teacherCompleted := make(map[uint]int64, len(teacherIDs))
+	for _, row := range append(asRequester, asOwner...) {
+		teacherTotal[row.UserID] += row.Total
+		teacherCompleted[row.UserID] += row.Completed
+	}
+
+	// Teacher activity in the month before asOf
+	monthBefore := asOf.AddDate(0, 0, -30)
+	var activityRows []struct {
+		UserID uint
+		Count  int64
+	}
+	recentActivity := make(map[uint]int64, len(teacherIDs))
+	config.DB.Unscoped().Model(&models.Skill{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ? AND created_at BETWEEN ? AND ?", teacherIDs, monthBefore, asOf).
+		Group("user_id").
+		Scan(&activityRows)
+	for _, row := range activityRows {
+		recentActivity[row.UserID] += row.Count
+	}
+	activityRows = nil
+	config.DB.Model(&models.Exchange{}).
+		Select("requester_id AS user_id, COUNT(*) AS count").
+		Where("requester_id IN ? AND created_at BETWEEN ? AND ?", teacherIDs, monthBefore, asOf).
+		Group("requester_id").
+		Scan(&activityRows)
+	for _, row := range activityRows {
+		recentActivity[row.UserID] += row.Count
+	}
+
+	var endorsementRows []struct {
+		SkillID uint
+		Count   int64
+	}
+	config.DB.Model(&models.Endorsement{}).
+		Select("skill_id, COUNT(*) AS count").
+		Where("skill_id IN ? AND created_at < ?", skillIDs, asOf).
+		Group("skill_id").
+		Scan(&endorsementRows)
+	endorsements := make(map[uint]int64, len(endorsementRows))
+	for _, row := range endorsementRows {
+		endorsements[row.SkillID] = row.Count
+	}
+
+	// Explicit interest from the seeker
+	var bookmarkedIDs, followedIDs []uint
+	config.DB.Model(&models.SkillBookmark{}).
+		Where("user_id = ? AND skill_id IN ? AND created_at < ?", seeker.ID, skillIDs, asOf).
+		Pluck("skill_id", &bookmarkedIDs)
+	config.DB.Model(&models.UserFollow{}).
+		Where("follower_id = ? AND followed_id IN ? AND created_at < ?", seeker.ID, teacherIDs, asOf).
+		Pluck("followed_id", &followedIDs)
+
+	var feedbackRows []struct {
+		Category string
+		Action   string
+		Count    int64
+	}
+	config.DB.Model(&models.MatchFeedback{}).
+		Select("category, action, COUNT(*) AS count").
+		Where("user_id = ? AND action IN ? AND updated_at < ?", seeker.ID, []string{"like", "dismiss"}, asOf).
+		Group("category, action").
+		Scan(&feedbackRows)
+	categoryFeedback := make(map[string]int64, len(feedbackRows))
+	for _, row := range feedbackRows {
+		if row.Action == "like" {
+			categoryFeedback[row.Category] += row.Count
+		} else {
+			categoryFeedback[row.Category] -= row.Count
+		}
+	}
+
+	// Categories each teacher offered at asOf, for mutual interest
+	teacherOffering := make(map[uint][]models.Skill, len(teacherIDs))
+	for _, skill := range rs.skillsAsOf(teacherIDs, "offering", asOf) {
+		teacherOffering[skill.UserID] = append(teacherOffering[skill.UserID], skill)
+	}
+
+	for _, offeredSkill := range offeredSkills {
+		teacherID := offeredSkill.UserID
+
+		// How well the offered skill fits what the seeker was looking for
+		categoryFit, levelFit := 0.0, 0.0
+		for _, seeking := range seekerSeeking {
+			fit := 0.0
+			if seeking.Category == offeredSkill.Category {
+				fit = 1
+			} else if containsString(ms.getCategoryMatches(seeking.Category), offeredSkill.Category) {
+				fit = 0.5
+			}
+			if fit == 0 {
+				continue
+			}
+			categoryFit = math.Max(categoryFit, fit)
+			if containsString(ms.getCompatibleLevels(seeking.Level), offeredSkill.Level) {
+				levelFit = 1
+			}
+		}
+
+		completionRate := 0.5 // Neutral for new users
+		if teacherTotal[teacherID] > 0 {
+			completionRate = float64(teacherCompleted[teacherID]) / float64(teacherTotal[teacherID])
+		}
+
+		// Mutual when the teacher offered something in a category the seeker was seeking
+		mutualInterest := 0.0
+		for _, seeking := range seekerSeeking {
+			for _, offering := range teacherOffering[teacherID] {
+				if seeking.Category == offering.Category {
+					mutualInterest = 1
+				}
+			}
+		}
+
+		bookmarked, follows := 0.0, 0.0
+		if containsID(bookmarkedIDs, offeredSkill.ID) {
+			bookmarked = 1
+		}
+		if containsID(followedIDs, teacherID) {
+			follows = 1
+		}
+
+		features[offeredSkill.ID] = []float64{
+			math.Log1p(float64(categoryHistory[offeredSkill.Category])),
+			categoryFit,
+			levelFit,
+			reviewAverages[teacherID] / 5,
+			math.Log1p(float64(reviewCounts[teacherID])),
+			completionRate,
+			math.Log1p(float64(recentActivity[teacherID])),
+			math.Log1p(float64(endorsements[offeredSkill.ID])),
+			mutualInterest,
+			bookmarked,
+			follows,
+			math.Max(math.Min(float64(categoryFeedback[offeredSkill.Category])/4, 1), -1),
+		}
+	}
+
+	return features
+}
+
+// BuildTrainingSet turns historical exchanges into labeled examples. Accepted and completed
+// exchanges are positives unless the requester rated them 2 or lower; rejected and cancelled
+// ones are negatives. For every positive, up to negativesPerPositive other skills the seeker
+// could have requested at the time are sampled as implicit negatives.
+func (rs *RankingService) BuildTrainingSet(negativesPerPositive int, seed int64) ([]TrainingExample, error) {
+	var exchanges []models.Exchange
+	if err := config.DB.Unscoped().
+		Preload("Requester", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Skill", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Skill.User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("status IN ?", []string{"accepted", "completed", "rejected", "cancelled"}).
+		Order("created_at ASC").
+		Find(&exchanges).Error; err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	ms := &MatchService{}
+	var examples []TrainingExample
+
+	for _, exchange := range exchanges {
+		if exchange.Skill.ID == 0 || exchange.Skill.SkillType != "offering" {
+			continue
+		}
+
+		label := rs.exchangeLabel(exchange)
+		skills := []models.Skill{exchange.Skill}
+
+		// Skills the seeker could have picked but didn't
+		if label == 1 && negativesPerPositive > 0 {
+			var requestedIDs []uint
+			config.DB.Unscoped().Model(&models.Exchange{}).Where("requester_id = ?", exchange.RequesterID).Pluck("skill_id", &requestedIDs)
+
+			var candidates []models.Skill
+			query := config.DB.Unscoped().
+				Where("skill_type = ? AND user_id != ? AND category IN ? AND created_at < ?",
+					"offering", exchange.RequesterID, ms.getCategoryMatches(exchange.Skill.Category), exchange.CreatedAt).
+				Where("deleted_at IS NULL OR deleted_at > ?", exchange.CreatedAt)
+			if len(requestedIDs) > 0 {
+				query = query.Where("id NOT IN ?", requestedIDs)
+			}
+			if err := query.Order("id ASC").Find(&candidates).Error; err != nil {
+				return nil, err
+			}
+
+			rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+			skills = append(skills, candidates[:min(negativesPerPositive, len(candidates))]...)
+		}
+
+		features := rs.Features(exchange.Requester, skills, exchange.CreatedAt)
+		for i, skill := range skills {
+			example := TrainingExample{Features: features[skill.ID], AsOf: exchange.CreatedAt}
+			if i == 0 {
+				example.Label = label
+			}
+			examples = append(examples, example)
+		}
+	}
+
+	return examples, nil
+}
+
+// SplitByTime holds out the most recent fraction of examples for evaluation
+func SplitByTime(examples []TrainingExample, holdout float64) (train, test []TrainingExample, err error) {
+	if holdout < 0 || holdout >= 1 {
+		return nil, nil, fmt.Errorf("holdout must be in [0, 1), got %v", holdout)
+	}
+
+	// Examples are built in exchange order, so the tail is the most recent
+	cut := len(examples) - int(float64(len(examples))*holdout)
+	return examples[:cut], examples[cut:], nil
+}
+
+func (rs *RankingService) exchangeLabel(exchange models.Exchange) float64 {
+	if exchange.Status == "rejected" || exchange.Status == "cancelled" {
+		return 0
+	}
+
+	var review models.Review
+	err := config.DB.Unscoped().Where("exchange_id = ? AND reviewer_id = ?", exchange.ID, exchange.RequesterID).First(&review).Error
+	if err == nil && review.Rating <= 2 {
+		return 0
+	}
+	return 1
+}
+
+// skillsAsOf returns the users' skills of a type that existed at asOf, including ones deleted later
+func (rs *RankingService) skillsAsOf(userIDs []uint, skillType string, asOf time.Time) []models.Skill {
+	var skills []models.Skill
+	config.DB.Unscoped().
+		Where("user_id IN ? AND skill_type = ? AND created_at <= ?", userIDs, skillType, asOf).
+		Where("deleted_at IS NULL OR deleted_at > ?", asOf).
+		Find(&skills)
+	return skills
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
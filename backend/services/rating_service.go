@@ -0,0 +1,44 @@
+package services
+
+import (
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+)
+
+type RatingService struct{}
+
+// RecalculateUserRating recalculates and updates user rating statistics
+func (rs *RatingService) RecalculateUserRating(userID uint) {
+	var reviews []models.Review
+	config.DB.Where("reviewee_id = ?", userID).Find(&reviews)
+
+	if len(reviews) == 0 {
+		// Delete user rating if no reviews exist
+		config.DB.Where("user_id = ?", userID).Delete(&models.UserRating{})
+		return
+	}
+
+	var totalRating int
+	var counts [6]int // index 0 unused, 1-5 for ratings
+
+	for _, review := range reviews {
+		totalRating += review.Rating
+		counts[review.Rating]++
+	}
+
+	averageRating := float64(totalRating) / float64(len(reviews))
+
+	userRating := models.UserRating{
+		UserID:        userID,
+		AverageRating: averageRating,
+		TotalReviews:  len(reviews),
+		Rating1Count:  counts[1],
+		Rating2Count:  counts[2],
+		Rating3Count:  counts[3],
+		Rating4Count:  counts[4],
+		Rating5Count:  counts[5],
+	}
+
+	// Upsert user rating
+	config.DB.Where("user_id = ?", userID).Assign(userRating).FirstOrCreate(&userRating)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Most skills listed in one alert
+const maxSkillsPerAlert = 10
+
+type SavedSearchService struct{}
+
+// Interval returns how often a saved search is evaluated; zero means never
+func (ss *SavedSearchService) Interval(frequency string) time.Duration {
+	switch frequency {
+	case "hourly":
+		return time.Hour
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// Filters converts a saved search into skill listing filters
+func (ss *SavedSearchService) Filters(search models.SavedSearch) SkillFilters {
+	return SkillFilters{
+		SkillType: search.SkillType,
+		Category:  search.Category,
+		Level:     search.Level,
+		Search:    search.Search,
+	}
+}
+
+// FindNewSkills returns active skills matching the search created after since, excluding the owner's own
+func (ss *SavedSearchService) FindNewSkills(search models.SavedSearch, since time.Time) ([]models.Skill, error) {
+	var skills []models.Skill
+	query := config.DB.Preload("User").
+		Where("is_active = ? AND user_id != ? AND created_at > ?", true, search.UserID, since)
+
+	err := ss.Filters(search).Apply(query).Order("created_at DESC").Find(&skills).Error
+	return skills, err
+}
+
+// RunDueSearches evaluates every saved search whose frequency interval has elapsed
+func (ss *SavedSearchService) RunDueSearches() error {
+	var searches []models.SavedSearch
+	if err := config.DB.Where("frequency != ?", "off").Find(&searches).Error; err != nil {
+		return fmt.Errorf("failed to load saved searches: %w", err)
+	}
+
+	now := time.Now()
+	for _, search := range searches {
+		since := search.CreatedAt
+		if search.LastRunAt != nil {
+			since = *search.LastRunAt
+		}
+
+		if now.Sub(since) < ss.Interval(search.Frequency) {
+			continue
+		}
+
+		if err := ss.run(search, since, now); err != nil {
+			log.Printf("Failed to run saved search %d: %v", search.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (ss *SavedSearchService) run(search models.SavedSearch, since, now time.Time) error {
+	skills, err := ss.FindNewSkills(search, since)
+	if err != nil {
+		return err
+	}
+
+	// Alerts are sent only if the run is recorded, so no skill is announced twice
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if len(skills) > 0 {
+			if err := ss.notify(tx, search, skills); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&search).Update("last_run_at", now).Error
+	})
+}
+
+func (ss *SavedSearchService) notify(tx *gorm.DB, search models.SavedSearch, skills []models.Skill) error {
+	if search.NotifyInApp {
+		skillIDs := make([]uint, 0, len(skills))
+		for _, skill := range skills {
+			skillIDs = append(skillIDs, skill.ID)
+		}
+
+		title := fmt.Sprintf("%d new skills match \"%s\"", len(skills), search.Name)
+		if len(skills) == 1 {
+			title = fmt.Sprintf("A new skill matches \"%s\"", search.Name)
+		}
+		body := skills[0].Title
+		if len(skills) > 1 {
+			body = fmt.Sprintf("%s and %d more", skills[0].Title, len(skills)-1)
+		}
+
+		if err := Notifications().PublishTo(tx, NotificationMessage{
+			Event:   EventSavedSearchMatched,
+			UserID:  search.UserID,
+			Subject: title,
+			Summary: body,
+			Link:    fmt.Sprintf("/skills?saved_search=%d", search.ID),
+			Data:    map[string]interface{}{"saved_search_id": search.ID, "skill_ids": skillIDs},
+		}, ChannelInApp); err != nil {
+			return err
+		}
+	}
+
+	if search.NotifyEmail {
+		emailService := &EmailService{}
+		if err := emailService.SendSavedSearchAlertNotification(tx, search, skills); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Postgres advisory lock key (ASCII "skill") shared by every instance; the one holding it runs the jobs
+const schedulerLockKey int64 = 0x736b696c6c
+
+// How often the scheduler checks for leadership and due jobs
+const schedulerTick = 30 * time.Second
+
+// ScheduledJob is a named function run on a cron schedule
+type ScheduledJob struct {
+	Name     string
+	Spec     string
+	schedule *CronSchedule
+	run      func() error
+	next     time.Time
+	running  int32
+}
+
+// Scheduler runs registered jobs on their cron schedules. When several instances run, only the
+// one holding the advisory lock runs jobs; if it goes away, another takes over on its next tick.
+type Scheduler struct {
+	jobs []*ScheduledJob
+	conn *sql.Conn // Session holding the advisory lock while this instance is leader
+}
+
+// Register adds a job. It must be called before Start.
+func (s *Scheduler) Register(name, spec string, run func() error) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.jobs = append(s.jobs, &ScheduledJob{Name: name, Spec: spec, schedule: schedule, run: run})
+	return nil
+}
+
+// Start runs the scheduler loop; it never returns
+func (s *Scheduler) Start() {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		s.tick(time.Now())
+		<-ticker.C
+	}
+}
+
+func (s *Scheduler) tick(now time.Time) {
+	if !s.holdLeadership(now) {
+		return
+	}
+
+	for _, job := range s.jobs {
+		if job.next.IsZero() || now.Before(job.next) {
+			continue
+		}
+		job.next = job.schedule.Next(now)
+		s.runJob(job)
+	}
+}
+
+// holdLeadership reports whether this instance is leader, trying to become it if not
+func (s *Scheduler) holdLeadership(now time.Time) bool {
+	ctx := context.Background()
+
+	if s.conn != nil {
+		if err := s.conn.PingContext(ctx); err == nil {
+			return true
+		}
+
+		// The lock dies with the session, so another instance may already have taken over
+		log.Printf("Scheduler lost its database session, giving up leadership")
+		s.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", schedulerLockKey)
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		log.Printf("Scheduler failed to get database handle: %v", err)
+		return false
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Printf("Scheduler failed to get database connection: %v", err)
+		return false
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", schedulerLockKey).Scan(&acquired); err != nil || !acquired {
+		conn.Close()
+		return false
+	}
+
+	s.conn = conn
+	log.Printf("Scheduler acquired leadership, running %d jobs", len(s.jobs))
+	s.loadSchedule(now)
+	return true
+}
+
+// loadSchedule sets each job's next run from its persisted state. A run missed while no instance
+// was leader happens right away, once.
+func (s *Scheduler) loadSchedule(now time.Time) {
+	for _, job := range s.jobs {
+		var state models.ScheduledJobState
+		err := config.DB.Where("name = ?", job.Name).First(&state).Error
+		if err != nil || state.LastRunAt == nil {
+			job.next = job.schedule.Next(now)
+			continue
+		}
+
+		job.next = job.schedule.Next(*state.LastRunAt)
+		if !job.next.IsZero() && job.next.Before(now) {
+			log.Printf("Job %s missed its run at %s, running it now", job.Name, job.next.Format(time.RFC3339))
+			job.next = now
+		}
+	}
+}
+
+// runJob runs a job in the background unless its previous run is still going
+func (s *Scheduler) runJob(job *ScheduledJob) {
+	if !atomic.CompareAndSwapInt32(&job.running, 0, 1) {
+		log.Printf("Job %s is still running, skipping this run", job.Name)
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&job.running, 0)
+
+		started := time.Now()
+		s.recordStart(job.Name, started)
+
+		err := s.safeRun(job)
+		if err != nil {
+			log.Printf("Job %s failed: %v", job.Name, err)
+		}
+		s.recordFinish(job.Name, started, err)
+	}()
+}
+
+// safeRun runs a job, turning a panic into an error so it doesn't take the server down
+func (s *Scheduler) safeRun(job *ScheduledJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.run()
+}
+
+func (s *Scheduler) recordStart(name string, started time.Time) {
+	state := models.ScheduledJobState{Name: name, LastRunAt: &started}
+	if err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_run_at", "updated_at"}),
+	}).Create(&state).Error; err != nil {
+		log.Printf("Failed to record start of job %s: %v", name, err)
+	}
+}
+
+func (s *Scheduler) recordFinish(name string, started time.Time, runErr error) {
+	finished := time.Now()
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+
+	if err := config.DB.Model(&models.ScheduledJobState{}).Where("name = ?", name).Updates(map[string]interface{}{
+		"last_finished_at": finished,
+		"last_duration_ms": finished.Sub(started).Milliseconds(),
+		"last_error":       lastError,
+		"updated_at":       finished,
+	}).Error; err != nil {
+		log.Printf("Failed to record result of job %s: %v", name, err)
+	}
+}
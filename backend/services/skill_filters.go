@@ -0,0 +1,37 @@
+package services
+
+import "gorm.io/gorm"
+
+// SkillFilters are the skill listing filters shared by GetSkills and saved searches
+type SkillFilters struct {
+	SkillType    string
+	Category     string
+	Level        string
+	Search       string
+	HasPortfolio bool
+}
+
+// Apply adds the filters to a query on the skills table
+func (f SkillFilters) Apply(query *gorm.DB) *gorm.DB {
+	if f.SkillType != "" {
+		query = query.Where("skill_type = ?", f.SkillType)
+	}
+
+	if f.Category != "" {
+		query = query.Where("category = ?", f.Category)
+	}
+
+	if f.Level != "" {
+		query = query.Where("level = ?", f.Level)
+	}
+
+	if f.Search != "" {
+		query = query.Where("title ILIKE ? OR description ILIKE ?", "%"+f.Search+"%", "%"+f.Search+"%")
+	}
+
+	if f.HasPortfolio {
+		query = query.Where("EXISTS (SELECT 1 FROM portfolio_items WHERE portfolio_items.skill_id = skills.id AND portfolio_items.deleted_at IS NULL)")
+	}
+
+	return query
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"skillswap-backend/config"
+)
+
+// ErrObjectNotFound is returned by a Storage when the key doesn't exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage is a blob store for uploaded files
+type Storage interface {
+	// Put stores size bytes read from r under key
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key; the caller must close it
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; deleting a missing key is not an error
+	Delete(key string) error
+}
+
+// FileStorage is the storage backend configured for this instance
+var FileStorage Storage
+
+// InitStorage sets up FileStorage from the configured driver
+func InitStorage() error {
+	switch config.AppConfig.StorageDriver {
+	case "local":
+		storage, err := NewLocalStorage(config.AppConfig.StorageLocalPath)
+		if err != nil {
+			return err
+		}
+		FileStorage = storage
+	case "s3":
+		storage, err := NewS3Storage(S3Options{
+			Endpoint:     config.AppConfig.S3Endpoint,
+			Region:       config.AppConfig.S3Region,
+			Bucket:       config.AppConfig.S3Bucket,
+			AccessKey:    config.AppConfig.S3AccessKey,
+			SecretKey:    config.AppConfig.S3SecretKey,
+			UsePathStyle: config.AppConfig.S3UsePathStyle,
+		})
+		if err != nil {
+			return err
+		}
+		FileStorage = storage
+	default:
+		return fmt.Errorf("unknown storage driver %q", config.AppConfig.StorageDriver)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"log"
+	"math"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/utils"
+	"sync"
+	"time"
+)
+
+// How long document frequencies are reused before being recomputed from active skills
+const textIndexTTL = 10 * time.Minute
+
+// textIndex holds document frequencies over the corpus of active skills
+type textIndex struct {
+	docFreq map[string]int
+	docs    int
+	builtAt time.Time
+}
+
+var (
+	currentTextIndex *textIndex
+	textIndexMu      sync.Mutex
+)
+
+// TextSimilarity returns the TF-IDF cosine similarity of two texts in [0, 1]. Terms that are
+// common across skills (e.g. "learn") count for little; rare shared terms count for a lot.
+func TextSimilarity(text1, text2 string) float64 {
+	terms1 := utils.AnalyzeText(text1)
+	terms2 := utils.AnalyzeText(text2)
+	if len(terms1) == 0 || len(terms2) == 0 {
+		return 0
+	}
+
+	index := getTextIndex()
+	vector1 := index.vector(terms1)
+	vector2 := index.vector(terms2)
+
+	dot, norm1, norm2 := 0.0, 0.0, 0.0
+	for term, weight := range vector1 {
+		dot += weight * vector2[term]
+		norm1 += weight * weight
+	}
+	for _, weight := range vector2 {
+		norm2 += weight * weight
+	}
+
+	if norm1 == 0 || norm2 == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(norm1) * math.Sqrt(norm2))
+}
+
+func getTextIndex() *textIndex {
+	textIndexMu.Lock()
+	defer textIndexMu.Unlock()
+
+	if currentTextIndex == nil || time.Since(currentTextIndex.builtAt) > textIndexTTL {
+		currentTextIndex = buildTextIndex()
+	}
+	return currentTextIndex
+}
+
+func buildTextIndex() *textIndex {
+	index := &textIndex{docFreq: make(map[string]int), builtAt: time.Now()}
+
+	var skills []models.Skill
+	if err := config.DB.Select("title", "description", "tags").Where("is_active = ?", true).Find(&skills).Error; err != nil {
+		// Without a corpus every term weighs the same, which is plain cosine similarity
+		log.Printf("Failed to build text similarity index: %v", err)
+		return index
+	}
+
+	for _, skill := range skills {
+		seen := make(map[string]bool)
+		for _, term := range utils.AnalyzeText(skill.Title + " " + skill.Description + " " + skill.Tags) {
+			if !seen[term] {
+				seen[term] = true
+				index.docFreq[term]++
+			}
+		}
+	}
+	index.docs = len(skills)
+
+	return index
+}
+
+// idf is the smoothed inverse document frequency; unseen terms get the highest weight
+func (ti *textIndex) idf(term string) float64 {
+	return math.Log(float64(1+ti.docs)/float64(1+ti.docFreq[term])) + 1
+}
+
+// vector weights each term by sublinear term frequency times idf
+func (ti *textIndex) vector(terms []string) map[string]float64 {
+	counts := make(map[string]int)
+	for _, term := range terms {
+		counts[term]++
+	}
+
+	vector := make(map[string]float64, len(counts))
+	for term, count := range counts {
+		vector[term] = (1 + math.Log(float64(count))) * ti.idf(term)
+	}
+	return vector
+}
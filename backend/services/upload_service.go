@@ -0,0 +1,294 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"skillswap-backend/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	thumbnailMaxDim = 256
+	// Refuse to decode images larger than this many pixels (decompression bombs)
+	thumbnailMaxPixels = 40_000_000
+)
+
+var (
+	ErrFileTooLarge        = errors.New("file too large")
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+)
+
+// Allowed content types, detected from the file contents rather than the client's header
+var (
+	imageContentTypes = map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+	}
+	chatFileContentTypes = map[string]string{
+		"application/pdf": ".pdf",
+		"application/zip": ".zip",
+		"text/plain":      ".txt",
+	}
+)
+
+type UploadService struct{}
+
+// SaveAvatar stores an avatar image with a thumbnail and replaces the user's previous avatar
+func (us *UploadService) SaveAvatar(userID uint, header *multipart.FileHeader) (*models.Attachment, error) {
+	attachment, err := us.save(userID, header, "avatar", nil, int64(config.AppConfig.AvatarMaxBytes), imageContentTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Point the profile at the new avatar and drop the old ones
+	avatarURL := us.ContentPath(attachment.ID, "")
+	if err := config.DB.Model(&models.User{}).Where("id = ?", userID).Update("avatar", avatarURL).Error; err != nil {
+		return nil, err
+	}
+
+	var previous []models.Attachment
+	config.DB.Where("owner_id = ? AND purpose = ? AND id != ?", userID, "avatar", attachment.ID).Find(&previous)
+	for _, old := range previous {
+		us.DeleteAttachment(old)
+	}
+
+	return attachment, nil
+}
+
+// SaveChatAttachment stores an image or document shared in a chat room
+func (us *UploadService) SaveChatAttachment(userID, chatRoomID uint, header *multipart.FileHeader) (*models.Attachment, error) {
+	allowed := make(map[string]string, len(imageContentTypes)+len(chatFileContentTypes))
+	for contentType, ext := range imageContentTypes {
+		allowed[contentType] = ext
+	}
+	for contentType, ext := range chatFileContentTypes {
+		allowed[contentType] = ext
+	}
+
+	return us.save(userID, header, "chat", &chatRoomID, int64(config.AppConfig.ChatAttachmentMaxBytes), allowed)
+}
+
+// SavePortfolioFile stores an image or PDF backing up a skill (work samples, certificates)
+func (us *UploadService) SavePortfolioFile(userID uint, header *multipart.FileHeader) (*models.Attachment, error) {
+	allowed := map[string]string{"application/pdf": ".pdf"}
+	for contentType, ext := range imageContentTypes {
+		allowed[contentType] = ext
+	}
+
+	return us.save(userID, header, "portfolio", nil, int64(config.AppConfig.PortfolioFileMaxBytes), allowed)
+}
+
+// DeleteAttachment removes the stored blobs and the attachment record
+func (us *UploadService) DeleteAttachment(attachment models.Attachment) {
+	if err := FileStorage.Delete(attachment.StorageKey); err != nil {
+		log.Printf("Failed to delete file %s: %v", attachment.StorageKey, err)
+	}
+	if attachment.ThumbnailKey != "" {
+		if err := FileStorage.Delete(attachment.ThumbnailKey); err != nil {
+			log.Printf("Failed to delete thumbnail %s: %v", attachment.ThumbnailKey, err)
+		}
+	}
+	config.DB.Delete(&attachment)
+}
+
+// IsImage reports whether the attachment should be rendered inline
+func (us *UploadService) IsImage(attachment models.Attachment) bool {
+	return strings.HasPrefix(attachment.ContentType, "image/")
+}
+
+// ContentPath is the unsigned download path; enough for avatars which are public
+func (us *UploadService) ContentPath(attachmentID uint, variant string) string {
+	path := fmt.Sprintf("/api/files/%d/content", attachmentID)
+	if variant != "" {
+		path += "?variant=" + url.QueryEscape(variant)
+	}
+	return path
+}
+
+// SignedURL returns a short-lived download path for a chat attachment
+func (us *UploadService) SignedURL(attachmentID uint, variant string) (string, time.Time) {
+	expires := time.Now().Add(time.Duration(config.AppConfig.FileURLTTLMinutes) * time.Minute)
+
+	query := url.Values{}
+	if variant != "" {
+		query.Set("variant", variant)
+	}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", us.signature(attachmentID, variant, expires.Unix()))
+
+	return fmt.Sprintf("/api/files/%d/content?%s", attachmentID, query.Encode()), expires
+}
+
+// VerifySignedURL checks a signature produced by SignedURL
+func (us *UploadService) VerifySignedURL(attachmentID uint, variant, expires, signature string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+
+	expected := us.signature(attachmentID, variant, expiresAt)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (us *UploadService) signature(attachmentID uint, variant string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	fmt.Fprintf(mac, "file:%d:%s:%d", attachmentID, variant, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (us *UploadService) save(userID uint, header *multipart.FileHeader, purpose string, chatRoomID *uint, maxBytes int64, allowed map[string]string) (*models.Attachment, error) {
+	if header.Size > maxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Sniff the real content type from the first 512 bytes
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	contentType := strings.TrimSpace(strings.Split(http.DetectContentType(sniff[:n]), ";")[0])
+
+	ext, ok := allowed[contentType]
+	if !ok {
+		return nil, ErrUnsupportedFileType
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+
+	folder := fmt.Sprintf("%ss/%d", purpose, userID)
+	if chatRoomID != nil {
+		folder = fmt.Sprintf("chat/%d", *chatRoomID)
+	}
+	key := folder + "/" + name + ext
+
+	if err := FileStorage.Put(key, file, header.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	attachment := models.Attachment{
+		OwnerID:     userID,
+		Purpose:     purpose,
+		ChatRoomID:  chatRoomID,
+		FileName:    sanitizeFileName(header.Filename, ext),
+		ContentType: contentType,
+		Size:        header.Size,
+		StorageKey:  key,
+	}
+
+	// Thumbnails are best effort; webp can't be decoded by the standard library
+	if _, err := file.Seek(0, io.SeekStart); err == nil {
+		if thumbnail, thumbType, err := makeThumbnail(file, contentType); err == nil {
+			thumbKey := folder + "/" + name + ".thumb" + imageContentTypes[thumbType]
+			if err := FileStorage.Put(thumbKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), thumbType); err == nil {
+				attachment.ThumbnailKey = thumbKey
+				attachment.HasThumbnail = true
+			}
+		}
+	}
+
+	if err := config.DB.Create(&attachment).Error; err != nil {
+		FileStorage.Delete(key)
+		if attachment.ThumbnailKey != "" {
+			FileStorage.Delete(attachment.ThumbnailKey)
+		}
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// makeThumbnail downsizes an image, keeping PNG for images that may have transparency
+func makeThumbnail(r io.ReadSeeker, contentType string) ([]byte, string, error) {
+	if !strings.HasPrefix(contentType, "image/") || contentType == "image/webp" {
+		return nil, "", ErrUnsupportedFileType
+	}
+
+	imgConfig, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if imgConfig.Width*imgConfig.Height > thumbnailMaxPixels {
+		return nil, "", ErrFileTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	thumbnail := utils.ResizeToFit(img, thumbnailMaxDim)
+
+	var buf bytes.Buffer
+	if contentType == "image/png" || contentType == "image/gif" {
+		err = png.Encode(&buf, thumbnail)
+		return buf.Bytes(), "image/png", err
+	}
+
+	err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+	return buf.Bytes(), "image/jpeg", err
+}
+
+func randomName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// sanitizeFileName keeps the base name the user uploaded for display, with the detected extension
+func sanitizeFileName(name, ext string) string {
+	base := strings.TrimSuffix(filepath.Base(strings.ReplaceAll(name, "\\", "/")), filepath.Ext(name))
+	base = strings.Map(func(r rune) rune {
+		if r < 32 || r == '"' || r == '/' {
+			return -1
+		}
+		return r
+	}, base)
+
+	if base == "" || base == "." {
+		base = "file"
+	}
+	if len(base) > 100 {
+		base = base[:100]
+	}
+	return base + ext
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"skillswap-backend/config"
+	"skillswap-backend/models"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EventWebhookPing is sent by the test-ping endpoint only
+const EventWebhookPing = "webhook.ping"
+
+// Webhook request headers
+const (
+	WebhookEventHeader     = "X-SkillSwap-Event"
+	WebhookDeliveryHeader  = "X-SkillSwap-Delivery" // The event ID, the same on every retry
+	WebhookSignatureHeader = "X-SkillSwap-Signature"
+)
+
+const (
+	maxWebhooksPerUser = 10
+	webhookTimeout     = 10 * time.Second
+	// Longest response body kept in the delivery log
+	maxWebhookResponseLog = 1024
+	// Delivery logs are kept this long
+	webhookDeliveryRetention = 30 * 24 * time.Hour
+)
+
+var (
+	ErrWebhookLimit          = fmt.Errorf("you can have at most %d webhooks", maxWebhooksPerUser)
+	errPrivateWebhookAddress = errors.New("webhook URL points to a private or loopback address")
+)
+
+// webhookClient refuses to connect to private addresses, checked on every connection so DNS can't
+// be used to sneak past URL validation, and doesn't follow redirects
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	if config.AppConfig.WebhookAllowPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+		return errPrivateWebhookAddress
+	}
+	return nil
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast()
+}
+
+// webhookEventAliases maps other names subscriptions may use to the event delivered
+var webhookEventAliases = map[string]string{
+	"exchange.created": EventExchangeRequested,
+}
+
+// WebhookEvents lists the events webhooks can subscribe to: every event routed to the webhook channel
+func WebhookEvents() []string {
+	var events []string
+	for event, channels := range DefaultNotificationRoutes {
+		if containsString(channels, ChannelWebhook) {
+			events = append(events, event)
+		}
+	}
+	sort.Strings(events)
+	return events
+}
+
+// SignWebhookPayload returns the signature header for a payload: the send time and an HMAC-SHA256,
+// keyed with the subscription secret, of "<timestamp>.<body>"
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+type WebhookService struct{}
+
+// ValidateURL checks that a webhook URL is absolute http(s), and not obviously private
+func (ws *WebhookService) ValidateURL(raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return errors.New("webhook URL must be an absolute http or https URL")
+	}
+	if target.User != nil {
+		return errors.New("webhook URL must not contain credentials")
+	}
+
+	if !config.AppConfig.WebhookAllowPrivateNetworks {
+		host := strings.ToLower(target.Hostname())
+		if ip := net.ParseIP(host); (ip != nil && isPrivateIP(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return errPrivateWebhookAddress
+		}
+	}
+	return nil
+}
+
+// ValidateEvents checks a subscription's events and returns them as stored: sorted, comma-separated,
+// with aliases resolved, or "*" for every event
+func (ws *WebhookService) ValidateEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return "", errors.New("subscribe to at least one event")
+	}
+
+	valid := WebhookEvents()
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if event == "*" {
+			return "*", nil
+		}
+		if alias, ok := webhookEventAliases[event]; ok {
+			event = alias
+		}
+		if !containsString(valid, event) {
+			return "", fmt.Errorf("unknown event %q", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+
+	sort.Strings(normalized)
+	return strings.Join(normalized, ","), nil
+}
+
+// EventList returns a subscription's events
+func (ws *WebhookService) EventList(subscription models.WebhookSubscription) []string {
+	return strings.Split(subscription.Events, ",")
+}
+
+// Create stores a subscription with a new secret, enforcing the per-user limit
+func (ws *WebhookService) Create(subscription *models.WebhookSubscription) error {
+	if subscription.UserID != nil {
+		var count int64
+		config.DB.Model(&models.WebhookSubscription{}).Where("user_id = ?", *subscription.UserID).Count(&count)
+		if count >= maxWebhooksPerUser {
+			return ErrWebhookLimit
+		}
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	subscription.Secret = secret
+	subscription.IsActive = true
+	return config.DB.Create(subscription).Error
+}
+
+// RotateSecret replaces a subscription's secret; payloads are signed with the new one from now on
+func (ws *WebhookService) RotateSecret(subscription *models.WebhookSubscription) error {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+	return config.DB.Model(subscription).Update("secret", secret).Error
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// newEventID returns a random ID identifying one event to webhook receivers
+func newEventID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return "evt_" + hex.EncodeToString(id)
+}
+
+// Subscribers returns the active subscriptions that receive a message: the recipient's own, and
+// every platform subscription, for its event
+func (ws *WebhookService) Subscribers(tx *gorm.DB, message NotificationMessage) ([]models.WebhookSubscription, error) {
+	query := tx.Where("is_active = ?", true)
+	if message.UserID != 0 {
+		query = query.Where("user_id = ? OR user_id IS NULL", message.UserID)
+	} else {
+		query = query.Where("user_id IS NULL")
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := query.Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	matching := subscriptions[:0]
+	for _, subscription := range subscriptions {
+		events := ws.EventList(subscription)
+		if containsString(events, "*") || containsString(events, message.Event) {
+			matching = append(matching, subscription)
+		}
+	}
+	return matching, nil
+}
+
+// Deliver posts a message to a subscription, signed with its secret, and logs the attempt. Any
+// response other than 2xx is an error.
+func (ws *WebhookService) Deliver(subscription models.WebhookSubscription, message NotificationMessage) (models.WebhookDelivery, error) {
+	payload := map[string]interface{}{
+		"id":      message.ID,
+		"event":   message.Event,
+		"user_id": message.UserID,
+		"subject": message.Subject,
+		"summary": message.Summary,
+		"link":    message.Link,
+		"data":    message.Data,
+		"sent_at": time.Now().UTC(),
+	}
+	if message.Link != "" {
+		payload["url"] = emailLink(message.Link)
+	}
+
+	// Leave out the recipient's address and the email body
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery := models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        message.ID,
+		Event:          message.Event,
+		RequestBody:    string(body),
+	}
+
+	started := time.Now()
+	resp, err := ws.post(subscription, message, body)
+	delivery.DurationMS = time.Since(started).Milliseconds()
+
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+		response, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseLog))
+		resp.Body.Close()
+
+		delivery.ResponseStatus = resp.StatusCode
+		delivery.ResponseBody = string(response)
+		delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+		if !delivery.Success {
+			delivery.Error = "webhook returned " + resp.Status
+		}
+	}
+
+	if err := config.DB.Create(&delivery).Error; err != nil {
+		return delivery, fmt.Errorf("failed to log webhook delivery: %w", err)
+	}
+	if !delivery.Success {
+		return delivery, errors.New(delivery.Error)
+	}
+	return delivery, nil
+}
+
+func (ws *WebhookService) post(subscription models.WebhookSubscription, message NotificationMessage, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SkillSwap-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, message.Event)
+	req.Header.Set(WebhookDeliveryHeader, message.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(subscription.Secret, time.Now().Unix(), body))
+
+	return webhookClient.Do(req)
+}
+
+// Ping sends a test event to a subscription right away, whatever events it subscribes to
+func (ws *WebhookService) Ping(subscription models.WebhookSubscription) (models.WebhookDelivery, error) {
+	message := NotificationMessage{
+		ID:      newEventID(),
+		Event:   EventWebhookPing,
+		Subject: "Test ping from SkillSwap",
+		Summary: "Your webhook is set up correctly",
+		Data:    map[string]interface{}{"subscription_id": subscription.ID},
+	}
+	if subscription.UserID != nil {
+		message.UserID = *subscription.UserID
+	}
+	return ws.Deliver(subscription, message)
+}
+
+// PurgeDeliveries deletes delivery logs past the retention period
+func (ws *WebhookService) PurgeDeliveries() error {
+	return config.DB.Where("created_at < ?", time.Now().Add(-webhookDeliveryRetention)).
+		Delete(&models.WebhookDelivery{}).Error
+}
@@ -0,0 +1,285 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Abbreviations and alternate spellings mapped to the terms they stand for
+var textSynonyms = map[string][]string{
+	"js":         {"javascript"},
+	"ecmascript": {"javascript"},
+	"ts":         {"typescript"},
+	"py":         {"python"},
+	"golang":     {"go"},
+	"cpp":        {"c++"},
+	"csharp":     {"c#"},
+	"nodejs":     {"node"},
+	"reactjs":    {"react"},
+	"vuejs":      {"vue"},
+	"postgres":   {"postgresql"},
+	"k8s":        {"kubernetes"},
+	"db":         {"database"},
+	"ml":         {"machine", "learning"},
+	"ai":         {"artificial", "intelligence"},
+	"ui":         {"user", "interface"},
+	"ux":         {"user", "experience"},
+	"dev":        {"development"},
+	"math":       {"mathematics"},
+	"maths":      {"mathematics"},
+	"stats":      {"statistics"},
+	"econ":       {"economics"},
+	"bio":        {"biology"},
+	"chem":       {"chemistry"},
+	"photo":      {"photography"},
+	"photos":     {"photography"},
+}
+
+var textStopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "am": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true, "been": true,
+	"but": true, "by": true, "can": true, "could": true, "do": true, "does": true, "for": true,
+	"from": true, "get": true, "had": true, "has": true, "have": true, "how": true, "i": true,
+	"if": true, "in": true, "into": true, "is": true, "it": true, "its": true, "just": true,
+	"me": true, "more": true, "my": true, "no": true, "not": true, "of": true, "on": true,
+	"or": true, "our": true, "so": true, "some": true, "than": true, "that": true, "the": true,
+	"their": true, "them": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "to": true, "up": true, "us": true, "very": true, "was": true, "we": true,
+	"were": true, "what": true, "when": true, "which": true, "who": true, "will": true,
+	"with": true, "would": true, "you": true, "your": true,
+}
+
+// AnalyzeText turns free text into normalized terms: lowercased, split into words,
+// abbreviations expanded, stop words removed and each word reduced to its stem
+func AnalyzeText(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+
+	var terms []string
+	for _, word := range words {
+		word = strings.TrimLeft(word, "+#") // Keep "c++" and "c#", drop stray symbols
+		if word == "" {
+			continue
+		}
+
+		expanded := []string{word}
+		if synonyms, ok := textSynonyms[word]; ok {
+			expanded = synonyms
+		}
+
+		for _, term := range expanded {
+			if textStopWords[term] {
+				continue
+			}
+			terms = append(terms, Stem(term))
+		}
+	}
+
+	return terms
+}
+
+// Stem reduces an English word to its stem with the Porter algorithm, so "cooking",
+// "cooked" and "cook" compare equal. Short and non-ASCII words are returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := []byte(word)
+	w = stemStep1a(w)
+	w = stemStep1b(w)
+	w = stemStep1c(w)
+	w = stemReplace(w, stemStep2Rules, 0)
+	w = stemReplace(w, stemStep3Rules, 0)
+	w = stemStep4(w)
+	w = stemStep5(w)
+	return string(w)
+}
+
+type stemRule struct {
+	suffix, replacement string
+}
+
+var stemStep2Rules = []stemRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+	{"abli", "able"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+	{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"},
+	{"fulness", "ful"}, {"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+var stemStep3Rules = []stemRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"},
+	{"ful", ""}, {"ness", ""},
+}
+
+// Longer suffixes come before the shorter ones they end with
+var stemStep4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment", "ent",
+	"ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func stemStep1a(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "sses"), hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func stemStep1b(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		if stemMeasure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem []byte
+	switch {
+	case hasSuffix(w, "ed") && stemHasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && stemHasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case stemEndsDoubleConsonant(stem):
+		if last := stem[len(stem)-1]; last != 'l' && last != 's' && last != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case stemMeasure(stem) == 1 && stemEndsCVC(stem):
+		return append(stem, 'e')
+	}
+	return stem
+}
+
+func stemStep1c(w []byte) []byte {
+	if hasSuffix(w, "y") && stemHasVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+	return w
+}
+
+// stemReplace applies the first rule whose suffix matches, if the remaining stem's measure exceeds minMeasure
+func stemReplace(w []byte, rules []stemRule, minMeasure int) []byte {
+	for _, rule := range rules {
+		if hasSuffix(w, rule.suffix) {
+			stem := w[:len(w)-len(rule.suffix)]
+			if stemMeasure(stem) > minMeasure {
+				return append(stem, rule.replacement...)
+			}
+			return w
+		}
+	}
+	return w
+}
+
+func stemStep4(w []byte) []byte {
+	for _, suffix := range stemStep4Suffixes {
+		if !hasSuffix(w, suffix) {
+			continue
+		}
+		// Prefer the longest matching suffix
+		for _, longer := range stemStep4Suffixes {
+			if len(longer) > len(suffix) && hasSuffix(w, longer) {
+				suffix = longer
+			}
+		}
+
+		stem := w[:len(w)-len(suffix)]
+		if suffix == "ion" && (len(stem) == 0 || (stem[len(stem)-1] != 's' && stem[len(stem)-1] != 't')) {
+			return w
+		}
+		if stemMeasure(stem) > 1 {
+			return stem
+		}
+		return w
+	}
+	return w
+}
+
+func stemStep5(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := stemMeasure(stem); m > 1 || (m == 1 && !stemEndsCVC(stem)) {
+			w = stem
+		}
+	}
+	if hasSuffix(w, "ll") && stemMeasure(w) > 1 {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return len(w) > len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+func stemIsConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !stemIsConsonant(w, i-1)
+	}
+	return true
+}
+
+// stemMeasure counts vowel-consonant sequences: the m in [C](VC)^m[V]
+func stemMeasure(w []byte) int {
+	m, i := 0, 0
+	for i < len(w) && stemIsConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !stemIsConsonant(w, i) {
+			i++
+		}
+		if i >= len(w) {
+			break
+		}
+		for i < len(w) && stemIsConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func stemHasVowel(w []byte) bool {
+	for i := range w {
+		if !stemIsConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func stemEndsDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && stemIsConsonant(w, n-1)
+}
+
+// stemEndsCVC reports a consonant-vowel-consonant ending where the last consonant isn't w, x or y (e.g. "hop")
+func stemEndsCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !stemIsConsonant(w, n-1) || stemIsConsonant(w, n-2) || !stemIsConsonant(w, n-3) {
+		return false
+	}
+	last := w[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}